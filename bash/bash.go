@@ -1,196 +1,447 @@
 package bash
 
 import (
-	"bytes"
-	"errors"
+	"encoding/json"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html"
-	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/transform"
 )
 
 // Quote struct
 type Quote struct {
-	Text   string
-	Rating string
-	ID     string
+	Text   string `json:"text"`
+	Rating Rating `json:"rating"`
+	ID     string `json:"id"`
+	// Date is zero if unknown
+	Date time.Time `json:"date,omitempty"`
+	// Comics is an address of comics drawn for quote
+	Comics string `json:"comics,omitempty"`
+	// Section is one of SectionMain, SectionAbyss and SectionBest
+	Section string `json:"section,omitempty"`
+	// URL is a permalink of quote, quotes of abyss have none
+	URL string `json:"url,omitempty"`
+
+	// LocalRating is a sum of votes of bot users
+	LocalRating int `json:"-"`
+}
+
+// DefaultURL of bash.im
+const DefaultURL = "http://bash.im"
+
+// DefaultScraper reads bash.im, package functions use it
+var DefaultScraper = NewScraper(DefaultURL)
+
+// Scraper is a QuoteSource which parses pages of bash.im or its mirror
+type Scraper struct {
+	BaseURL string
+	// Client makes requests, DefaultClient is used if it is nil
+	Client *Client
+	// MaxResults limits number of quotes returned by Search, DefaultMaxResults is used if it is zero
+	MaxResults int
+}
+
+// DefaultMaxResults of search
+const DefaultMaxResults = 100
+
+// NewScraper creates scraper of site at baseURL
+func NewScraper(baseURL string) *Scraper {
+	return &Scraper{BaseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *Scraper) client() *Client {
+	if s.Client == nil {
+		return DefaultClient
+	}
+	return s.Client
 }
 
 // GetQuotes comment... wtf
 func GetQuotes(topic string) ([]Quote, error) {
-	address := fmt.Sprintf("http://bash.im/%s", topic)
+	return DefaultScraper.GetQuotes(topic)
+}
 
-	res, err := http.Get(address)
-	if err != nil {
-		return nil, fmt.Errorf("can't get page: %s", err)
-	}
-	defer res.Body.Close()
+// GetQuoteByID gets quote by id
+func GetQuoteByID(id string) (Quote, error) {
+	return DefaultScraper.ByID(id)
+}
 
-	utf8, err := charset.NewReader(res.Body, res.Header.Get("Content-Type"))
-	if err != nil {
-		return nil, fmt.Errorf("GetQuotes: %s", err)
-	}
+// Search func searches on bash
+func Search(req string) ([]Quote, error) {
+	return DefaultScraper.Search(req)
+}
 
-	data, err := ioutil.ReadAll(utf8)
-	if err != nil {
-		return nil, fmt.Errorf("GetQuotes: %s", err)
+// Plus request
+func Plus(id string) (VoteResult, error) {
+	return DefaultScraper.Vote(id, VotePlus)
+}
+
+// Minus request
+func Minus(id string) (VoteResult, error) {
+	return DefaultScraper.Vote(id, VoteMinus)
+}
+
+// Bayan request
+func Bayan(id string) (VoteResult, error) {
+	return DefaultScraper.Vote(id, VoteBayan)
+}
+
+// QuoteToString convers Quote to String
+func QuoteToString(quote Quote) string {
+	str := ""
+
+	str += quote.Text + "\n\n"
+	str += "# " + quote.ID
+	if quote.Section == SectionAbyss {
+		str += " из Бездны"
+	}
+	if !quote.Date.IsZero() {
+		str += " от " + quote.Date.In(moscow).Format("02.01.2006 15:04")
+	}
+	str += "\n"
+	str += "+ " + quote.Rating.String() + " (у нас " + fmt.Sprintf("%+d", quote.LocalRating) + ")\n"
+	if quote.Comics != "" {
+		str += "Комикс: " + quote.Comics + "\n"
 	}
+	if quote.URL != "" {
+		str += quote.URL + "\n"
+	}
+
+	return str
+}
 
-	node, err := html.Parse(bytes.NewReader(data))
+// GetQuotes returns quotes from page of topic
+func (s *Scraper) GetQuotes(topic string) ([]Quote, error) {
+	node, err := s.getPage(fmt.Sprintf("%s/%s", s.BaseURL, topic))
 	if err != nil {
 		return nil, fmt.Errorf("GetQuotes: %s", err)
 	}
 
-	return getQuotesFromHTML(node)
+	return getQuotesFromHTML(node, s.BaseURL, SectionMain)
 }
 
-// GetQuoteByID gets quote by id
-func GetQuoteByID(id string) (Quote, error) {
-	address := fmt.Sprintf("http://bash.im/quote/%s", id)
+// Random returns page of random quotes
+func (s *Scraper) Random() ([]Quote, error) {
+	return s.GetQuotes("random")
+}
 
-	res, err := http.Get(address)
-	if err != nil {
-		return Quote{}, err
+// ByID gets quote by id
+func (s *Scraper) ByID(id string) (Quote, error) {
+	if !isNumber(id) {
+		return Quote{}, ErrNotFound
 	}
-	defer res.Body.Close()
 
-	utf8, err := charset.NewReader(res.Body, res.Header.Get("Content-Type"))
-	if err != nil {
-		return Quote{}, err
+	node, err := s.getPage(fmt.Sprintf("%s/quote/%s", s.BaseURL, id))
+	if status, ok := err.(*StatusError); ok && status.Code == http.StatusNotFound {
+		return Quote{}, ErrNotFound
 	}
-
-	data, err := ioutil.ReadAll(utf8)
 	if err != nil {
 		return Quote{}, err
 	}
 
-	node, err := html.Parse(bytes.NewReader(data))
+	quotes, err := getQuotesFromHTML(node, s.BaseURL, SectionMain)
 	if err != nil {
 		return Quote{}, err
 	}
+	for _, quote := range quotes {
+		if quote.ID == id {
+			return quote, nil
+		}
+	}
 
-	quotes, err := getQuotesFromHTML(node)
-	if err != nil {
-		return Quote{}, err
+	if isPending(node, id) {
+		return Quote{}, ErrPending
 	}
-	if len(quotes) == 0 {
-		return Quote{}, errors.New("no quote")
+	return Quote{}, ErrNotFound
+}
+
+// isPending reports whether page has quote with id but without text, so
+// site shows quote which is not approved yet
+func isPending(node *html.Node, id string) bool {
+	for _, q := range getOutermostByClass(node, "quote") {
+		if quoteID(q) == id && getText(getFirstByClass(q, "text", "quote__body")) == "" {
+			return true
+		}
 	}
-	return quotes[0], nil
+	return false
 }
 
-// QuoteToString convers Quote to String
-func QuoteToString(quote Quote) string {
-	str := ""
+// Vote sends vote for quote
+func (s *Scraper) Vote(id string, vote Vote) (VoteResult, error) {
+	act, ok := voteActs[vote]
+	if !ok {
+		return VoteResult{Status: VoteRejected}, fmt.Errorf("unknown vote %d", vote)
+	}
 
-	str += quote.Text + "\n\n"
-	str += "# " + quote.ID + "\n"
-	str += "+ " + quote.Rating + "\n"
+	address := fmt.Sprintf("%s/quote/%s/%s", s.BaseURL, id, act)
 
-	return str
-}
+	data := fmt.Sprintf("quote=%s&act=%s", id, act)
 
-// Plus request
-func Plus(id string) {
-	feedback(id, "rulez")
+	body, err := s.client().Post(address, data, s.BaseURL+"/")
+	if status, ok := err.(*StatusError); ok && status.Code < 500 {
+		if status.Code == http.StatusConflict || status.Code == http.StatusTooManyRequests {
+			return VoteResult{Status: VoteRepeated}, nil
+		}
+		return VoteResult{Status: VoteRejected}, nil
+	}
+	if err != nil {
+		return VoteResult{Status: VoteNetworkError}, err
+	}
+	return parseVoteAnswer(body), nil
 }
 
-// Minus request
-func Minus(id string) {
-	feedback(id, "sux")
-}
+// parseVoteAnswer reads new rating from answer of site, which is a bare
+// number or JSON with rating field
+func parseVoteAnswer(body []byte) VoteResult {
+	text := strings.TrimSpace(string(body))
+	if strings.Contains(strings.ToLower(text), "уже") {
+		return VoteResult{Status: VoteRepeated}
+	}
 
-// Bayan request
-func Bayan(id string) {
-	feedback(id, "bayan")
+	result := VoteResult{Status: VoteAccepted, Rating: ParseRating(text)}
+	if result.Rating.Known {
+		return result
+	}
+
+	var answer interface{}
+	if json.Unmarshal(body, &answer) == nil {
+		if rating, ok := findRating(answer); ok {
+			result.Rating = NewRating(rating)
+		}
+	}
+	return result
 }
 
-func feedback(id string, act string) {
-	address := fmt.Sprintf("http://bash.im/quote/%s/%s", id, act)
+func findRating(v interface{}) (int, bool) {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	if rating, ok := fields["rating"].(float64); ok {
+		return int(rating), true
+	}
+	for _, field := range fields {
+		if rating, ok := findRating(field); ok {
+			return rating, true
+		}
+	}
+	return 0, false
+}
 
-	data := fmt.Sprintf("quote=%s&act=%s", id, act)
+// Search searches quotes on site, results of all pages are collected up to MaxResults
+func (s *Scraper) Search(req string) ([]Quote, error) {
+	max := s.MaxResults
+	if max <= 0 {
+		max = DefaultMaxResults
+	}
 
-	client := &http.Client{}
-	req, _ := http.NewRequest("POST", address, strings.NewReader(data))
-	req.Header.Add("Referer", "http://bash.im/")
+	var quotes []Quote
+	it := s.SearchPages(req)
+	for len(quotes) < max && it.Next() {
+		quotes = append(quotes, it.Quotes()...)
+	}
+	if len(quotes) > max {
+		quotes = quotes[:max]
+	}
+	return quotes, it.Err()
+}
 
-	resp, _ := client.Do(req)
-	defer resp.Body.Close()
+// SearchPages returns iterator over pages of search results
+func (s *Scraper) SearchPages(req string) *SearchIterator {
+	return &SearchIterator{scraper: s, query: req, seen: make(map[string]bool)}
 }
 
-// Search func searches on bash
-func Search(req string) ([]Quote, error) {
-	//url encode Windows1251
-	address := fmt.Sprintf("http://bash.im/index?text=%s", req)
+// searchAddress percent-encodes query in windows-1251 as site expects
+func (s *Scraper) searchAddress(req string, page int) (string, error) {
+	query, err := encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder()).String(req)
+	if err != nil {
+		return "", fmt.Errorf("can't encode query: %s", err)
+	}
 
-	buf := new(bytes.Buffer)
-	wToWin1251 := transform.NewWriter(buf, charmap.Windows1251.NewEncoder())
-	io.Copy(wToWin1251, strings.NewReader(address))
-	wToWin1251.Close()
+	address := fmt.Sprintf("%s/index?text=%s", s.BaseURL, url.QueryEscape(query))
+	if page > 1 {
+		address += "&page=" + strconv.Itoa(page)
+	}
+	return address, nil
+}
 
-	address1251 := buf.String()
+// SearchIterator reads pages of search results one by one
+type SearchIterator struct {
+	scraper *Scraper
+	query   string
+	page    int
+	last    int
+	seen    map[string]bool
+	quotes  []Quote
+	err     error
+	done    bool
+}
 
-	res, err := http.Get(address1251)
-	if err != nil {
-		return nil, err
+// Next loads next page, it returns false when there are no more pages or an error occurred
+func (it *SearchIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	it.page++
+	if it.last != 0 && it.page > it.last {
+		it.done = true
+		return false
 	}
-	defer res.Body.Close()
 
-	utf8, err := charset.NewReader(res.Body, res.Header.Get("Content-Type"))
+	address, err := it.scraper.searchAddress(it.query, it.page)
 	if err != nil {
-		return nil, err
+		it.err, it.done = err, true
+		return false
 	}
-
-	data, err := ioutil.ReadAll(utf8)
+	node, err := it.scraper.getPage(address)
 	if err != nil {
-		return nil, err
+		it.err, it.done = err, true
+		return false
+	}
+	if pager := pagerInput(node); pager != nil && it.last == 0 {
+		if max, ok := getAttribute(pager, "max"); ok {
+			it.last, _ = strconv.Atoi(max)
+		}
 	}
 
-	node, err := html.Parse(bytes.NewReader(data))
+	quotes, err := getQuotesFromHTML(node, it.scraper.BaseURL, SectionMain)
 	if err != nil {
-		return nil, err
+		it.err, it.done = err, true
+		return false
 	}
 
-	return getQuotesFromHTML(node)
+	// pages after the last one repeat it
+	it.quotes = it.quotes[:0]
+	for _, quote := range quotes {
+		if !it.seen[quote.ID] {
+			it.seen[quote.ID] = true
+			it.quotes = append(it.quotes, quote)
+		}
+	}
+	if len(it.quotes) == 0 {
+		it.done = true
+		return false
+	}
+	return true
 }
 
-func getQuotesFromHTML(node *html.Node) ([]Quote, error) {
+// Quotes returns quotes of current page
+func (it *SearchIterator) Quotes() []Quote {
+	return it.quotes
+}
 
-	quoteNodes := getElementByClass(node, "quote")
-	quotes := make([]Quote, len(quoteNodes))
+// Err returns error which stopped iteration
+func (it *SearchIterator) Err() error {
+	return it.err
+}
 
-	for i, q := range quoteNodes {
-		idClass := getElementByClass(q, "id")
-		if len(idClass) == 0 {
-			continue
+// Capabilities of scraper, it can do everything
+func (s *Scraper) Capabilities() Capabilities {
+	return CanRandom | CanSearch | CanByID | CanVote | CanBrowse | CanComics
+}
+
+// getQuotesFromHTML reads quotes of both old (div.quote) and new
+// (article.quote with data-quote) layouts of bash.im, elements without id
+// or text are skipped. Links are resolved against baseURL.
+func getQuotesFromHTML(node *html.Node, baseURL string, section string) ([]Quote, error) {
+	quoteNodes := getOutermostByClass(node, "quote")
+	quotes := make([]Quote, 0, len(quoteNodes))
+
+	for _, q := range quoteNodes {
+		quote := Quote{
+			ID:      quoteID(q),
+			Rating:  ParseRating(getText(getFirstByClass(q, "rating", "quote__total"))),
+			Text:    getText(getFirstByClass(q, "text", "quote__body")),
+			Date:    parseDate(getText(getFirstByClass(q, "date", "quote__header_date"))),
+			Section: section,
 		}
-		idNode := idClass[0]
-		ratingClass := getElementByClass(q, "rating")
-		if len(ratingClass) == 0 {
+		if quote.ID == "" || quote.Text == "" {
 			continue
 		}
-		ratingNode := ratingClass[0]
-		textClass := getElementByClass(q, "text")
-		if len(textClass) == 0 {
-			continue
+		if comics := getFirstByClass(q, "comics", "quote__strips"); comics != nil {
+			quote.Comics = absURL(baseURL, firstHref(comics))
 		}
-		textNode := textClass[0]
-		quotes[i].ID = idNode.FirstChild.Data[1:]
-		quotes[i].Rating = ratingNode.FirstChild.Data
-		quotes[i].Text = ""
-		for c := textNode.FirstChild; c != nil; c = c.NextSibling {
-			if c.Data == "br" {
-				quotes[i].Text += "\n"
-			} else {
-				quotes[i].Text += c.Data
-			}
+		if section != SectionAbyss {
+			quote.URL = baseURL + "/quote/" + quote.ID
 		}
+		quotes = append(quotes, quote)
 	}
 	return quotes, nil
 }
+
+// firstHref returns address of element if it is a link or of first link inside it
+func firstHref(n *html.Node) string {
+	if links := hrefs(n); len(links) > 0 {
+		return links[0]
+	}
+	return ""
+}
+
+// absURL resolves href of page of site at baseURL
+func absURL(baseURL, href string) string {
+	if href == "" {
+		return ""
+	}
+	base, err := url.Parse(baseURL + "/")
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// quoteID takes id from data-quote attribute, "#123" link or /quote/123 address
+func quoteID(q *html.Node) string {
+	if id, ok := getAttribute(q, "data-quote"); ok && isNumber(id) {
+		return id
+	}
+
+	link := getFirstByClass(q, "id", "quote__header_permalink")
+	if link == nil {
+		return ""
+	}
+	if id := strings.TrimPrefix(getText(link), "#"); isQuoteID(id) {
+		return id
+	}
+	if href, ok := getAttribute(link, "href"); ok {
+		if id := path.Base(href); isNumber(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// isQuoteID accepts numbers and ids of abyss like AA123
+func isQuoteID(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package bash
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// TestGetQuotesFromHTML compares quotes of saved pages with testdata/*.golden
+func TestGetQuotesFromHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+	}{
+		{"old_layout", SectionMain},
+		{"new_layout", SectionMain},
+		{"abyss", SectionAbyss},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := os.Open(filepath.Join("testdata", test.name+".html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			node, err := parsePage(file, "")
+			if err != nil {
+				t.Fatalf("can't parse page: %s", err)
+			}
+			quotes, err := getQuotesFromHTML(node, DefaultURL, test.section)
+			if err != nil {
+				t.Fatalf("can't get quotes: %s", err)
+			}
+
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			encoder.SetIndent("", "\t")
+			if err := encoder.Encode(quotes); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.Bytes()
+
+			golden := filepath.Join("testdata", test.name+".golden")
+			if *update {
+				if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("quotes differ from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestParseQuotesSection(t *testing.T) {
+	const quote = `<article class="quote" data-quote="1"><div class="quote__body">text</div></article>`
+	tests := []struct {
+		name    string
+		page    string
+		section string
+	}{
+		{"saved from", "<!-- saved from url=(0033)https://bash.im/best/20190604 -->\n<html><body>" + quote, SectionBest},
+		{"canonical", `<html><head><link rel="canonical" href="https://bash.im/abysstop"></head><body>` + quote, SectionAbyss},
+		{"og url", `<html><head><meta property="og:url" content="https://bash.im/quote/1"></head><body>` + quote, SectionMain},
+		{"index", `<html><head><link rel="canonical" href="https://bash.im/"></head><body>` + quote, SectionMain},
+		{"no address", "<html><body>" + quote, SectionMain},
+	}
+	for _, test := range tests {
+		quotes, err := ParseQuotes(strings.NewReader(test.page))
+		if err != nil {
+			t.Fatalf("%s: can't parse: %s", test.name, err)
+		}
+		if len(quotes) != 1 || quotes[0].Section != test.section {
+			t.Errorf("%s: quotes = %v, want section %s", test.name, quotes, test.section)
+		}
+	}
+
+	for name, section := range map[string]string{"abyss": SectionAbyss, "new_layout": SectionMain, "old_layout": SectionMain} {
+		file, err := os.Open(filepath.Join("testdata", name+".html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		quotes, err := ParseQuotes(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("%s: can't parse: %s", name, err)
+		}
+		for _, quote := range quotes {
+			if quote.Section != section {
+				t.Errorf("%s: quote %s is of section %s, want %s", name, quote.ID, quote.Section, section)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package bash
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Listings of bash.im
+const (
+	ListingNew       = "new"
+	ListingBestDay   = "best"
+	ListingBestMonth = "bestmonth"
+	ListingBestYear  = "bestyear"
+	ListingByRating  = "byrating"
+	ListingAbyss     = "abyss"
+	ListingAbyssTop  = "abysstop"
+	ListingAbyssBest = "abyssbest"
+)
+
+// Listings in order they are shown
+var Listings = []string{
+	ListingNew,
+	ListingBestDay,
+	ListingBestMonth,
+	ListingBestYear,
+	ListingByRating,
+	ListingAbyss,
+	ListingAbyssTop,
+	ListingAbyssBest,
+}
+
+// ErrUnknownListing is returned for listing not in Listings
+var ErrUnknownListing = errors.New("unknown listing")
+
+// Page of listing, tokens are opaque and empty if there is no such page
+type Page struct {
+	Quotes []Quote
+	// Token of this page, it can be given to Browse to get it again
+	Token string
+	Next  string
+	Prev  string
+	// Random listing has no pages, every request returns new quotes
+	Random bool
+}
+
+// Browser is a QuoteSource which lists sections page by page
+type Browser interface {
+	// Browse returns page of listing, empty token means the first page
+	Browse(listing string, token string) (Page, error)
+}
+
+// paging scheme of listing
+type paging int
+
+const (
+	// pagingNone has the only page, every request returns new quotes
+	pagingNone paging = iota
+	// pagingDesc counts pages down to 1, the first page has the biggest number
+	pagingDesc
+	// pagingAsc counts pages up from 1
+	pagingAsc
+	// pagingDay, pagingMonth and pagingYear page by dates going back in time
+	pagingDay
+	pagingMonth
+	pagingYear
+)
+
+var listings = map[string]struct {
+	path    string
+	section string
+	paging  paging
+}{
+	ListingNew:       {"index", SectionMain, pagingDesc},
+	ListingBestDay:   {"best", SectionBest, pagingDay},
+	ListingBestMonth: {"bestmonth", SectionBest, pagingMonth},
+	ListingBestYear:  {"bestyear", SectionBest, pagingYear},
+	ListingByRating:  {"byrating", SectionMain, pagingAsc},
+	ListingAbyss:     {"abyss", SectionAbyss, pagingNone},
+	ListingAbyssTop:  {"abysstop", SectionAbyss, pagingNone},
+	ListingAbyssBest: {"abyssbest", SectionAbyss, pagingDay},
+}
+
+// date layouts of tokens and addresses of dated pages
+var datePaths = map[paging]struct {
+	token string
+	path  string
+	step  func(t time.Time, n int) time.Time
+}{
+	pagingDay: {"20060102", "20060102", func(t time.Time, n int) time.Time { return t.AddDate(0, 0, n) }},
+	pagingMonth: {"200601", "2006/01", func(t time.Time, n int) time.Time {
+		return time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	}},
+	pagingYear: {"2006", "2006", func(t time.Time, n int) time.Time { return t.AddDate(n, 0, 0) }},
+}
+
+// Browse returns page of listing
+func (s *Scraper) Browse(listing string, token string) (Page, error) {
+	l, ok := listings[listing]
+	if !ok {
+		return Page{}, ErrUnknownListing
+	}
+
+	switch l.paging {
+	case pagingNone:
+		quotes, err := s.listingQuotes(l.path, l.section)
+		return Page{Quotes: quotes, Random: true}, err
+
+	case pagingDesc, pagingAsc:
+		address := l.path
+		if token != "" {
+			address += "/" + token
+		}
+		node, err := s.getPage(fmt.Sprintf("%s/%s", s.BaseURL, address))
+		if err != nil {
+			return Page{}, err
+		}
+		quotes, err := getQuotesFromHTML(node, s.BaseURL, l.section)
+		if err != nil {
+			return Page{}, err
+		}
+		return numberedPage(node, quotes, token, l.paging), nil
+
+	default:
+		dates := datePaths[l.paging]
+		today := time.Now().In(moscow)
+		date := today
+		if token != "" {
+			var err error
+			date, err = time.ParseInLocation(dates.token, token, moscow)
+			if err != nil {
+				return Page{}, fmt.Errorf("invalid page %q", token)
+			}
+		}
+
+		quotes, err := s.listingQuotes(l.path+"/"+date.Format(dates.path), l.section)
+		if err != nil {
+			return Page{}, err
+		}
+		page := Page{
+			Quotes: quotes,
+			Token:  date.Format(dates.token),
+			Next:   dates.step(date, -1).Format(dates.token),
+		}
+		if next := dates.step(date, 1); !next.After(today) {
+			page.Prev = next.Format(dates.token)
+		}
+		return page, nil
+	}
+}
+
+func (s *Scraper) listingQuotes(path string, section string) ([]Quote, error) {
+	node, err := s.getPage(fmt.Sprintf("%s/%s", s.BaseURL, path))
+	if err != nil {
+		return nil, err
+	}
+	return getQuotesFromHTML(node, s.BaseURL, section)
+}
+
+// numberedPage finds current and last page numbers in pager of page
+func numberedPage(node *html.Node, quotes []Quote, token string, p paging) Page {
+	current, _ := strconv.Atoi(token)
+	last := 0
+	if pager := pagerInput(node); pager != nil {
+		if value, ok := getAttribute(pager, "value"); ok && current == 0 {
+			current, _ = strconv.Atoi(value)
+		}
+		if max, ok := getAttribute(pager, "max"); ok {
+			last, _ = strconv.Atoi(max)
+		}
+	}
+	if current == 0 {
+		current = 1
+		if p == pagingDesc {
+			current = last
+		}
+	}
+
+	page := Page{Quotes: quotes, Token: strconv.Itoa(current)}
+	older, newer := current+1, current-1
+	if p == pagingDesc {
+		older, newer = current-1, current+1
+	}
+	if older >= 1 && (last == 0 || older <= last) && len(quotes) > 0 {
+		page.Next = strconv.Itoa(older)
+	}
+	if newer >= 1 && (last == 0 || newer <= last) {
+		page.Prev = strconv.Itoa(newer)
+	}
+	return page
+}
+
+// pagerInput returns input with page number of old or new layout
+func pagerInput(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.Data == "input" {
+		if name, _ := getAttribute(n, "name"); name == "page" || checkClass(n, "pager__input") {
+			return n
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if result := pagerInput(c); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// Browse returns page of listing and caches its quotes
+func (s *CachedSource) Browse(listing string, token string) (Page, error) {
+	browser, ok := s.QuoteSource.(Browser)
+	if !ok {
+		return Page{}, ErrUnsupported
+	}
+
+	page, err := browser.Browse(listing, token)
+	s.Cache.Add(page.Quotes...)
+	return page, err
+}
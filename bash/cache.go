@@ -0,0 +1,192 @@
+package bash
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+)
+
+var (
+	cacheHits   = metrics.NewCounter("bash_cache_hits_total", "Quotes found in cache")
+	cacheMisses = metrics.NewCounter("bash_cache_misses_total", "Quotes not found in cache")
+)
+
+// CacheStore keeps cached quotes outside of process
+type CacheStore interface {
+	// LoadQuote returns quote and time it was cached at or an error if there is no such quote
+	LoadQuote(id string) (Quote, time.Time, error)
+	StoreQuote(quote Quote, at time.Time) error
+}
+
+// Cache keeps recently seen quotes by id, least recently used quotes are
+// evicted when cache is full and quotes older than ttl are never returned
+type Cache struct {
+	// Store is optional, quotes are written through to it
+	Store CacheStore
+
+	mu     sync.Mutex
+	size   int
+	ttl    time.Duration
+	order  *list.List
+	items  map[string]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	quote Quote
+	at    time.Time
+}
+
+// CacheStats of cache
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	Len    int
+}
+
+// NewCache creates cache of at most size quotes, zero ttl means quotes never expire
+func NewCache(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns cached quote
+func (c *Cache) Get(id string) (Quote, bool) {
+	quote, ok := c.get(id)
+	if !ok && c.Store != nil {
+		var at time.Time
+		var err error
+		quote, at, err = c.Store.LoadQuote(id)
+		if ok = err == nil && c.fresh(at); ok {
+			c.add(quote, at)
+		}
+	}
+
+	c.mu.Lock()
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+
+	if ok {
+		cacheHits.Inc()
+	} else {
+		cacheMisses.Inc()
+	}
+	return quote, ok
+}
+
+// Add puts quotes to cache
+func (c *Cache) Add(quotes ...Quote) {
+	now := time.Now()
+	for _, quote := range quotes {
+		if quote.ID == "" {
+			continue
+		}
+		c.add(quote, now)
+		if c.Store != nil {
+			c.Store.StoreQuote(quote, now)
+		}
+	}
+}
+
+// Stats returns number of hits, misses and cached quotes
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Len: c.order.Len()}
+}
+
+func (c *Cache) get(id string) (Quote, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return Quote{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !c.fresh(entry.at) {
+		c.order.Remove(elem)
+		delete(c.items, id)
+		return Quote{}, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.quote, true
+}
+
+func (c *Cache) add(quote Quote, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[quote.ID]; ok {
+		elem.Value = &cacheEntry{quote, at}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[quote.ID] = c.order.PushFront(&cacheEntry{quote, at})
+	for c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).quote.ID)
+	}
+}
+
+func (c *Cache) fresh(at time.Time) bool {
+	return c.ttl == 0 || time.Since(at) < c.ttl
+}
+
+// CachedSource fills cache from every page of source and takes quotes by
+// id from cache when it can
+type CachedSource struct {
+	QuoteSource
+	Cache *Cache
+}
+
+// NewCachedSource wraps source with cache
+func NewCachedSource(source QuoteSource, cache *Cache) *CachedSource {
+	return &CachedSource{QuoteSource: source, Cache: cache}
+}
+
+// Unwrap returns wrapped source
+func (s *CachedSource) Unwrap() QuoteSource {
+	return s.QuoteSource
+}
+
+// Random returns random quotes of source
+func (s *CachedSource) Random() ([]Quote, error) {
+	quotes, err := s.QuoteSource.Random()
+	s.Cache.Add(quotes...)
+	return quotes, err
+}
+
+// Search returns quotes of source matching query
+func (s *CachedSource) Search(query string) ([]Quote, error) {
+	quotes, err := s.QuoteSource.Search(query)
+	s.Cache.Add(quotes...)
+	return quotes, err
+}
+
+// ByID returns cached quote or asks source for it
+func (s *CachedSource) ByID(id string) (Quote, error) {
+	if quote, ok := s.Cache.Get(id); ok {
+		return quote, nil
+	}
+
+	quote, err := s.QuoteSource.ByID(id)
+	if err != nil {
+		return quote, err
+	}
+	s.Cache.Add(quote)
+	return quote, nil
+}
@@ -0,0 +1,102 @@
+package bash
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// memoryCacheStore keeps stored quotes in map
+type memoryCacheStore map[string]cacheEntry
+
+func (s memoryCacheStore) LoadQuote(id string) (Quote, time.Time, error) {
+	entry, ok := s[id]
+	if !ok {
+		return Quote{}, time.Time{}, errors.New("no quote")
+	}
+	return entry.quote, entry.at, nil
+}
+
+func (s memoryCacheStore) StoreQuote(quote Quote, at time.Time) error {
+	s[quote.ID] = cacheEntry{quote, at}
+	return nil
+}
+
+func TestCacheLRU(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		add  []string
+		get  []string
+		// kept are ids found after get and add of "x"
+		kept    []string
+		evicted []string
+	}{
+		{"fits", 3, []string{"1", "2"}, nil, []string{"1", "2", "x"}, nil},
+		{"oldest evicted", 2, []string{"1", "2"}, nil, []string{"2", "x"}, []string{"1"}},
+		{"used kept", 2, []string{"1", "2"}, []string{"1"}, []string{"1", "x"}, []string{"2"}},
+		{"re-added kept", 2, []string{"1", "2", "1"}, nil, []string{"1", "x"}, []string{"2"}},
+		{"no id", 2, []string{"1", ""}, nil, []string{"1", "x"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := NewCache(test.size, 0)
+			for _, id := range test.add {
+				cache.Add(Quote{ID: id})
+			}
+			for _, id := range test.get {
+				cache.Get(id)
+			}
+			cache.Add(Quote{ID: "x"})
+
+			for _, id := range test.kept {
+				if _, ok := cache.Get(id); !ok {
+					t.Errorf("quote %s is not cached", id)
+				}
+			}
+			for _, id := range test.evicted {
+				if _, ok := cache.Get(id); ok {
+					t.Errorf("quote %s is not evicted", id)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := NewCache(10, 20*time.Millisecond)
+	cache.Add(Quote{ID: "1"})
+	if _, ok := cache.Get("1"); !ok {
+		t.Fatal("fresh quote is not cached")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Get("1"); ok {
+		t.Error("expired quote is returned")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Len != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	store := memoryCacheStore{
+		"old": {Quote{ID: "old"}, time.Now().Add(-2 * time.Hour)},
+		"new": {Quote{ID: "new"}, time.Now()},
+	}
+	cache := NewCache(10, time.Hour)
+	cache.Store = store
+
+	if _, ok := cache.Get("new"); !ok {
+		t.Error("fresh quote of store is not returned")
+	}
+	if _, ok := cache.Get("old"); ok {
+		t.Error("expired quote of store is returned")
+	}
+
+	cache.Add(Quote{ID: "added"})
+	if _, ok := store["added"]; !ok {
+		t.Error("added quote is not written to store")
+	}
+}
@@ -0,0 +1,278 @@
+package bash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client errors
+var (
+	ErrCircuitOpen  = errors.New("bash.im is unavailable, circuit is open")
+	ErrBodyTooLarge = errors.New("response body is too large")
+)
+
+// StatusError is returned when site answers with error status
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+// DefaultUserAgent of client
+const DefaultUserAgent = "go_bash_telgram_bot (+https://github.com/AnisimoffNikita/go_bash_telgram_bot)"
+
+// ClientConfig of bash.im client
+type ClientConfig struct {
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	MaxBodySize    int64
+	UserAgent      string
+
+	// Retries of idempotent requests, delay grows twice with every retry
+	Retries    int
+	RetryDelay time.Duration
+
+	// Circuit opens after BreakerFailures failures in a row and lets a
+	// request through after BreakerCooldown
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+// DefaultClientConfig returns config with default values
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		ConnectTimeout:  5 * time.Second,
+		ReadTimeout:     10 * time.Second,
+		MaxBodySize:     4 << 20,
+		UserAgent:       DefaultUserAgent,
+		Retries:         2,
+		RetryDelay:      200 * time.Millisecond,
+		BreakerFailures: 5,
+		BreakerCooldown: 30 * time.Second,
+	}
+}
+
+// DefaultClient is shared by scrapers which have no own client
+var DefaultClient = NewClient(DefaultClientConfig())
+
+// Client makes requests to bash.im
+type Client struct {
+	config  ClientConfig
+	http    *http.Client
+	breaker *Breaker
+}
+
+// NewClient creates client with given config
+func NewClient(config ClientConfig) *Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   config.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   config.ConnectTimeout,
+		ResponseHeaderTimeout: config.ReadTimeout,
+		MaxIdleConnsPerHost:   8,
+	}
+
+	return &Client{
+		config: config,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   config.ConnectTimeout + config.ReadTimeout,
+		},
+		breaker: NewBreaker(config.BreakerFailures, config.BreakerCooldown),
+	}
+}
+
+// BreakerState returns state of circuit breaker
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// Get reads body of page and its content type, failed requests are retried with jitter
+func (c *Client) Get(address string) ([]byte, string, error) {
+	var err error
+	delay := c.config.RetryDelay
+	for attempt := 0; attempt <= c.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay/2 + time.Duration(rand.Int63n(int64(delay)+1)))
+			delay *= 2
+		}
+
+		var res response
+		res, err = c.do("GET", address, nil, "")
+		if err == nil || !res.retry {
+			return res.body, res.contentType, err
+		}
+	}
+	return nil, "", err
+}
+
+// Post sends form and returns body of answer, it is never retried
+func (c *Client) Post(address string, form string, referer string) ([]byte, error) {
+	res, err := c.do("POST", address, strings.NewReader(form), referer)
+	return res.body, err
+}
+
+// response of request, retry is set when request failed because of site
+// or network and may be repeated
+type response struct {
+	body        []byte
+	contentType string
+	retry       bool
+}
+
+// do makes request through circuit breaker
+func (c *Client) do(method, address string, body io.Reader, referer string) (response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return response{}, err
+	}
+
+	res, err := c.request(method, address, body, referer)
+	if err != nil && res.retry {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+	return res, err
+}
+
+func (c *Client) request(method, address string, body io.Reader, referer string) (response, error) {
+	req, err := http.NewRequest(method, address, body)
+	if err != nil {
+		return response{}, err
+	}
+	req.Header.Set("User-Agent", c.config.UserAgent)
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return response{retry: true}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		return response{retry: true}, &StatusError{Code: res.StatusCode, Status: method + " " + address + ": " + res.Status}
+	}
+	if res.StatusCode >= 400 {
+		return response{}, &StatusError{Code: res.StatusCode, Status: method + " " + address + ": " + res.Status}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, c.config.MaxBodySize+1))
+	if err != nil {
+		return response{retry: true}, err
+	}
+	if int64(len(data)) > c.config.MaxBodySize {
+		return response{}, ErrBodyTooLarge
+	}
+
+	return response{body: data, contentType: res.Header.Get("Content-Type")}, nil
+}
+
+// BreakerState is a state of circuit breaker
+type BreakerState int
+
+// Breaker states
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("state(%d)", int(s))
+}
+
+// Breaker fails fast after several failures in a row
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewBreaker creates closed breaker
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// State returns current state
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *Breaker) state() BreakerState {
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return BreakerClosed
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+// Allow returns ErrCircuitOpen if request must not be made, only one
+// request is let through while breaker is half-open
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state() {
+	case BreakerOpen:
+		return ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success closes breaker
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure counts failure and opens breaker if there are too many of them
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
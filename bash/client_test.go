@@ -0,0 +1,74 @@
+package bash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		failures int
+		wait     time.Duration
+		want     BreakerState
+	}{
+		{"closed", 0, 0, BreakerClosed},
+		{"below threshold", 2, 0, BreakerClosed},
+		{"open", 3, 0, BreakerOpen},
+		{"half-open after cooldown", 3, 2 * cooldown, BreakerHalfOpen},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBreaker(3, cooldown)
+			for i := 0; i < test.failures; i++ {
+				b.Failure()
+			}
+			time.Sleep(test.wait)
+			if state := b.State(); state != test.want {
+				t.Errorf("state = %s, want %s", state, test.want)
+			}
+			if err := b.Allow(); (err == ErrCircuitOpen) != (test.want == BreakerOpen) {
+				t.Errorf("Allow() = %v in state %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+	const cooldown = 10 * time.Millisecond
+	b := NewBreaker(1, cooldown)
+	b.Failure()
+	time.Sleep(2 * cooldown)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe is not allowed: %v", err)
+	}
+	if err := b.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("second probe is allowed: %v", err)
+	}
+
+	// failed probe opens breaker for another cooldown
+	b.Failure()
+	if state := b.State(); state != BreakerOpen {
+		t.Fatalf("state after failed probe = %s", state)
+	}
+
+	time.Sleep(2 * cooldown)
+	b.Allow()
+	b.Success()
+	if state := b.State(); state != BreakerClosed {
+		t.Errorf("state after successful probe = %s", state)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("disabled breaker does not allow: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package bash
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Comic is a strip drawn for quote
+type Comic struct {
+	// Image is an address of picture
+	Image string
+	// URL is an address of page of comic
+	URL     string
+	QuoteID string
+}
+
+// ComicsSource finds comics of quotes
+type ComicsSource interface {
+	// Comic returns comic of quote or ErrNotFound if there is none
+	Comic(quote Quote) (Comic, error)
+	// RandomComics returns some comics
+	RandomComics() ([]Comic, error)
+	// Download reads picture of comic
+	Download(address string) ([]byte, error)
+}
+
+var imageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+func isImage(address string) bool {
+	ext := strings.ToLower(path.Ext(address))
+	for _, e := range imageExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Comic finds picture on page of comic of quote
+func (s *Scraper) Comic(quote Quote) (Comic, error) {
+	if quote.Comics == "" {
+		return Comic{}, ErrNotFound
+	}
+	comic := Comic{URL: quote.Comics, QuoteID: quote.ID}
+	if isImage(quote.Comics) {
+		comic.Image = quote.Comics
+		return comic, nil
+	}
+
+	node, err := s.getPage(quote.Comics)
+	if err != nil {
+		return Comic{}, err
+	}
+	comic.Image = absURL(s.BaseURL, comicImage(node))
+	if comic.Image == "" {
+		return Comic{}, ErrNotFound
+	}
+	return comic, nil
+}
+
+// RandomComics returns comics of strips page in random order
+func (s *Scraper) RandomComics() ([]Comic, error) {
+	node, err := s.getPage(s.BaseURL + "/strips")
+	if err != nil {
+		return nil, err
+	}
+
+	var comics []Comic
+	for _, strip := range getOutermostByClass(node, "strip") {
+		comic := Comic{Image: absURL(s.BaseURL, comicImage(strip))}
+		for _, href := range hrefs(strip) {
+			switch {
+			case strings.Contains(href, "/quote/") && comic.QuoteID == "":
+				comic.QuoteID = path.Base(strings.TrimSuffix(href, "/"))
+			case strings.Contains(href, "/strip") && comic.URL == "":
+				comic.URL = absURL(s.BaseURL, href)
+			}
+		}
+		if comic.Image != "" && isNumber(comic.QuoteID) {
+			comics = append(comics, comic)
+		}
+	}
+
+	rand.Shuffle(len(comics), func(i, j int) {
+		comics[i], comics[j] = comics[j], comics[i]
+	})
+	return comics, nil
+}
+
+// Download reads file from site
+func (s *Scraper) Download(address string) ([]byte, error) {
+	data, _, err := s.client().Get(address)
+	if err != nil {
+		return nil, fmt.Errorf("can't download %s: %s", address, err)
+	}
+	return data, nil
+}
+
+// comicImage returns address of picture of old or new layout
+func comicImage(n *html.Node) string {
+	img := getElementByID(n, "cm_strip")
+	if img == nil {
+		img = getFirstByClass(n, "strip__image", "quote__strips_img", "comics__image")
+	}
+	if img == nil {
+		if container := getFirstByClass(n, "strip", "comics", "quote__strips"); container != nil {
+			img = firstElement(container, "img")
+		}
+	}
+	if img == nil {
+		return ""
+	}
+
+	if src, ok := getAttribute(img, "data-src"); ok && src != "" {
+		return src
+	}
+	src, _ := getAttribute(img, "src")
+	return src
+}
+
+func firstElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if result := firstElement(c, tag); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// hrefs returns addresses of all links inside element
+func hrefs(n *html.Node) []string {
+	var result []string
+	if n.Type == html.ElementNode && n.Data == "a" {
+		if href, ok := getAttribute(n, "href"); ok {
+			result = append(result, href)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result = append(result, hrefs(c)...)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package bash
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+)
+
+var scrapeFailures = metrics.NewCounter("bash_scrape_failures_total",
+	"Failed fetches of bash.im pages")
+
+var (
+	fetchMu     sync.Mutex
+	lastSuccess time.Time
+	lastFailure time.Time
+)
+
+// LastFetch returns times of last successful and last failed page fetch
+func LastFetch() (success time.Time, failure time.Time) {
+	fetchMu.Lock()
+	defer fetchMu.Unlock()
+	return lastSuccess, lastFailure
+}
+
+// getPage downloads page and parses it as html
+func (s *Scraper) getPage(address string) (*html.Node, error) {
+	node, err := s.fetchPage(address)
+
+	// missing page is an answer of working site
+	if status, ok := err.(*StatusError); ok && status.Code < 500 {
+		return nil, err
+	}
+
+	fetchMu.Lock()
+	if err != nil {
+		lastFailure = time.Now()
+	} else {
+		lastSuccess = time.Now()
+	}
+	fetchMu.Unlock()
+
+	if err != nil {
+		scrapeFailures.Inc()
+	}
+	return node, err
+}
+
+func (s *Scraper) fetchPage(address string) (*html.Node, error) {
+	data, contentType, err := s.client().Get(address)
+	if _, ok := err.(*StatusError); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get page: %s", err)
+	}
+
+	return parsePage(bytes.NewReader(data), contentType)
+}
+
+// parsePage decodes page to utf-8 and parses it, empty contentType makes
+// charset be detected from page itself
+func parsePage(r io.Reader, contentType string) (*html.Node, error) {
+	utf8, err := charset.NewReader(r, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(utf8)
+	if err != nil {
+		return nil, err
+	}
+
+	return html.Parse(bytes.NewReader(data))
+}
+
+// ParseQuotes reads quotes from saved page of bash.im, section of quotes is
+// taken from the page
+func ParseQuotes(r io.Reader) ([]Quote, error) {
+	node, err := parsePage(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return getQuotesFromHTML(node, DefaultURL, pageSection(node))
+}
+
+// savedFrom is a comment browsers put into saved pages
+var savedFrom = regexp.MustCompile(`saved from url=\(\d+\)(\S+)`)
+
+// pageSection returns section of listing at address of saved page, pages
+// without address are of the abyss if their quotes have no numbers
+func pageSection(node *html.Node) string {
+	if address, err := url.Parse(pageAddress(node)); err == nil && address.Path != "" {
+		first := strings.SplitN(strings.Trim(address.Path, "/"), "/", 2)[0]
+		for _, listing := range listings {
+			if listing.path == first {
+				return listing.section
+			}
+		}
+		return SectionMain
+	}
+
+	for _, q := range getOutermostByClass(node, "quote") {
+		if id := quoteID(q); id != "" && !isNumber(id) {
+			return SectionAbyss
+		}
+	}
+	return SectionMain
+}
+
+// pageAddress returns canonical address of page or address it was saved from
+func pageAddress(n *html.Node) string {
+	switch n.Type {
+	case html.CommentNode:
+		if m := savedFrom.FindStringSubmatch(n.Data); m != nil {
+			return m[1]
+		}
+	case html.ElementNode:
+		rel, _ := getAttribute(n, "rel")
+		property, _ := getAttribute(n, "property")
+		switch {
+		case n.Data == "link" && rel == "canonical":
+			href, _ := getAttribute(n, "href")
+			return href
+		case n.Data == "meta" && property == "og:url":
+			content, _ := getAttribute(n, "content")
+			return content
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if address := pageAddress(c); address != "" {
+			return address
+		}
+	}
+	return ""
+}
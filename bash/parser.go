@@ -1,6 +1,8 @@
 package bash
 
 import (
+	"strings"
+
 	"golang.org/x/net/html"
 )
 
@@ -13,11 +15,17 @@ func getAttribute(n *html.Node, key string) (string, bool) {
 	return "", false
 }
 
+// checkClass reports whether class is one of classes of element
 func checkClass(n *html.Node, class string) bool {
 	if n.Type == html.ElementNode {
 		s, ok := getAttribute(n, "class")
-		if ok && s == class {
-			return true
+		if !ok {
+			return false
+		}
+		for _, c := range strings.Fields(s) {
+			if c == class {
+				return true
+			}
 		}
 	}
 	return false
@@ -68,3 +76,64 @@ func traverseID(n *html.Node, id string) *html.Node {
 func getElementByID(n *html.Node, id string) *html.Node {
 	return traverseID(n, id)
 }
+
+// getFirstByClass returns first element having any of classes
+func getFirstByClass(n *html.Node, classes ...string) *html.Node {
+	for _, class := range classes {
+		if checkClass(n, class) {
+			return n
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result := getFirstByClass(c, classes...)
+		if result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// getOutermostByClass returns elements having class which are not inside
+// of other such element
+func getOutermostByClass(n *html.Node, class string) []*html.Node {
+	if checkClass(n, class) {
+		return []*html.Node{n}
+	}
+
+	var result []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		result = append(result, getOutermostByClass(c, class)...)
+	}
+	return result
+}
+
+// getText returns text of element, <br> starts a new line and other line
+// breaks are treated as spaces, lines are trimmed
+func getText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(n.Data))
+		case n.Type == html.ElementNode && n.Data == "br":
+			b.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,174 @@
+package bash
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// prefetchRetryDelay is a pause after failed fetch of random quotes
+const prefetchRetryDelay = 5 * time.Second
+
+// Prefetcher keeps buffer of unseen random quotes of source, the buffer is
+// refilled in background when it drops below low water mark. Only one fetch
+// of source runs at a time, callers finding buffer empty wait for it.
+type Prefetcher struct {
+	source   QuoteSource
+	size     int
+	lowWater int
+
+	mu     sync.Mutex
+	quotes []Quote
+	ids    map[string]bool
+	flight *flight
+
+	refill chan struct{}
+	quit   chan struct{}
+}
+
+// NewPrefetcher creates prefetcher of size quotes, Start must be called to fill it
+func NewPrefetcher(source QuoteSource, size, lowWater int) *Prefetcher {
+	return &Prefetcher{
+		source:   source,
+		size:     size,
+		lowWater: lowWater,
+		ids:      make(map[string]bool),
+		refill:   make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start begins filling buffer in background
+func (p *Prefetcher) Start() {
+	go p.run()
+	p.wake()
+}
+
+// Stop stops background filling
+func (p *Prefetcher) Stop() {
+	close(p.quit)
+}
+
+// flight is a fetch of source shared by everyone waiting for quotes
+type flight struct {
+	done  chan struct{}
+	added int
+	err   error
+}
+
+// Next returns quote from buffer. Only if buffer is empty it waits for
+// running fetch or starts one, ErrNotFound is returned when source has no
+// unseen quotes.
+func (p *Prefetcher) Next() (Quote, error) {
+	for {
+		if quote, ok := p.pop(); ok {
+			return quote, nil
+		}
+
+		added, err := p.fetch()
+		if err != nil {
+			return Quote{}, err
+		}
+		if added == 0 {
+			return Quote{}, ErrNotFound
+		}
+	}
+}
+
+func (p *Prefetcher) pop() (Quote, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.quotes) == 0 {
+		return Quote{}, false
+	}
+
+	quote := p.quotes[0]
+	p.quotes = p.quotes[1:]
+	delete(p.ids, quote.ID)
+	if len(p.quotes) <= p.lowWater {
+		p.wake()
+	}
+	return quote, true
+}
+
+// fetch puts quotes of source to buffer and returns number of added ones,
+// if source is already fetched the running fetch is waited for
+func (p *Prefetcher) fetch() (int, error) {
+	p.mu.Lock()
+	if f := p.flight; f != nil {
+		p.mu.Unlock()
+		<-f.done
+		return f.added, f.err
+	}
+	f := &flight{done: make(chan struct{})}
+	p.flight = f
+	p.mu.Unlock()
+
+	var quotes []Quote
+	quotes, f.err = p.source.Random()
+	f.added = p.add(quotes)
+
+	p.mu.Lock()
+	p.flight = nil
+	p.mu.Unlock()
+	close(f.done)
+	return f.added, f.err
+}
+
+// Len returns number of buffered quotes
+func (p *Prefetcher) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.quotes)
+}
+
+func (p *Prefetcher) wake() {
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Prefetcher) run() {
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-p.refill:
+		}
+
+		for p.Len() < p.size {
+			added, err := p.fetch()
+			if err != nil {
+				select {
+				case <-p.quit:
+					return
+				case <-time.After(prefetchRetryDelay):
+				}
+				break
+			}
+			if added == 0 {
+				break
+			}
+		}
+	}
+}
+
+// add puts unseen quotes to buffer in random order and returns number of added ones
+func (p *Prefetcher) add(quotes []Quote) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	added := 0
+	for _, i := range rand.Perm(len(quotes)) {
+		quote := quotes[i]
+		if len(p.quotes) >= p.size || quote.ID == "" || p.ids[quote.ID] {
+			continue
+		}
+		p.quotes = append(p.quotes, quote)
+		p.ids[quote.ID] = true
+		added++
+	}
+	return added
+}
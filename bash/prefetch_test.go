@@ -0,0 +1,115 @@
+package bash
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedSource returns new page of random quotes on every call
+type pagedSource struct {
+	*Static
+	pageSize int
+	delay    time.Duration
+	calls    int32
+}
+
+func (s *pagedSource) Random() ([]Quote, error) {
+	page := int(atomic.AddInt32(&s.calls, 1))
+	time.Sleep(s.delay)
+
+	quotes := make([]Quote, s.pageSize)
+	for i := range quotes {
+		id := strconv.Itoa(page*1000 + i)
+		quotes[i] = Quote{ID: id, Text: "quote " + id}
+	}
+	return quotes, nil
+}
+
+func TestPrefetcherSharesFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		waiters int
+		pages   int32
+	}{
+		{"one waiter", 1, 1},
+		{"waiters fit page", 10, 1},
+		{"waiters need two pages", 15, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &pagedSource{Static: NewStatic(nil), pageSize: 10, delay: 50 * time.Millisecond}
+			// buffer is not started, so only waiters fetch
+			p := NewPrefetcher(source, 10, 0)
+
+			var wg sync.WaitGroup
+			seen := make(chan string, test.waiters)
+			for i := 0; i < test.waiters; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					quote, err := p.Next()
+					if err != nil {
+						t.Errorf("can't get quote: %s", err)
+						return
+					}
+					seen <- quote.ID
+				}()
+			}
+			wg.Wait()
+			close(seen)
+
+			ids := make(map[string]bool)
+			for id := range seen {
+				if ids[id] {
+					t.Errorf("quote %s is handed out twice", id)
+				}
+				ids[id] = true
+			}
+			if calls := atomic.LoadInt32(&source.calls); calls != test.pages {
+				t.Errorf("source is fetched %d times, want %d", calls, test.pages)
+			}
+		})
+	}
+}
+
+func TestPrefetcherRefillsInBackground(t *testing.T) {
+	source := &pagedSource{Static: NewStatic(nil), pageSize: 5}
+	p := NewPrefetcher(source, 10, 3)
+	p.Start()
+	defer p.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for p.Len() < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("buffer has %d quotes, want 10", p.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if calls := atomic.LoadInt32(&source.calls); calls != 2 {
+		t.Errorf("source is fetched %d times to fill buffer, want 2", calls)
+	}
+
+	for i := 0; i < 7; i++ {
+		if _, err := p.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline = time.Now().Add(time.Second)
+	for p.Len() < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("buffer is not refilled below low water, %d quotes", p.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPrefetcherEmptySource(t *testing.T) {
+	p := NewPrefetcher(NewStatic(nil), 10, 0)
+	if _, err := p.Next(); err != ErrNotFound {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
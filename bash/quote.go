@@ -0,0 +1,91 @@
+package bash
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sections of bash.im
+const (
+	SectionMain  = "main"
+	SectionAbyss = "abyss"
+	SectionBest  = "best"
+)
+
+// Unrated is shown instead of rating of new quotes
+const Unrated = "???"
+
+// Rating of quote on site, it is unknown for new quotes and quotes of abyss
+type Rating struct {
+	Value int
+	Known bool
+}
+
+// NewRating returns known rating
+func NewRating(value int) Rating {
+	return Rating{Value: value, Known: true}
+}
+
+// ParseRating parses rating shown on site, anything but a number is unknown rating
+func ParseRating(s string) Rating {
+	value, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return Rating{}
+	}
+	return NewRating(value)
+}
+
+func (r Rating) String() string {
+	if !r.Known {
+		return Unrated
+	}
+	return strconv.Itoa(r.Value)
+}
+
+// MarshalJSON writes known rating as a number and unknown as "???"
+func (r Rating) MarshalJSON() ([]byte, error) {
+	if !r.Known {
+		return json.Marshal(Unrated)
+	}
+	return json.Marshal(r.Value)
+}
+
+// UnmarshalJSON reads rating given as a number or a string
+func (r *Rating) UnmarshalJSON(data []byte) error {
+	var value int
+	if err := json.Unmarshal(data, &value); err == nil {
+		*r = NewRating(value)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*r = ParseRating(s)
+	return nil
+}
+
+// dateLayouts of old and new bash.im pages
+var dateLayouts = []string{
+	"2006-01-02 15:04",
+	"02.01.2006 в 15:04",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// moscow is a time zone of dates on bash.im
+var moscow = time.FixedZone("MSK", 3*60*60)
+
+// parseDate parses date shown on site, zero time is returned if it is unknown
+func parseDate(s string) time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range dateLayouts {
+		if date, err := time.ParseInLocation(layout, s, moscow); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,183 @@
+package bash
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// pendingPage is quote page whose text mentions words site used for pending quotes
+const pendingPage = `<html><body><section class="quotes">
+<article class="quote" data-quote="100">
+	<div class="quote__body">&lt;xxx&gt; ожидает ответа на рассмотрении, как в Бездне</div>
+</article>
+</section></body></html>`
+
+func TestScraperByID(t *testing.T) {
+	layout, err := ioutil.ReadFile(filepath.Join("testdata", "new_layout.html"))
+	if err != nil {
+		t.Fatalf("can't read page: %s", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/quote/404":
+			http.NotFound(w, r)
+		case "/quote/100", "/quote/101":
+			fmt.Fprint(w, pendingPage)
+		default:
+			w.Write(layout)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultClientConfig()
+	config.Retries = 0
+	scraper := NewScraper(server.URL)
+	scraper.Client = NewClient(config)
+
+	tests := []struct {
+		id  string
+		err error
+	}{
+		{"456789", nil},
+		{"100", nil},
+		{"101", ErrNotFound},
+		{"456791", ErrPending},
+		{"999", ErrNotFound},
+		{"404", ErrNotFound},
+		{"AA123", ErrNotFound},
+	}
+	for _, test := range tests {
+		quote, err := scraper.ByID(test.id)
+		if err != test.err {
+			t.Errorf("ByID(%s) error = %v, want %v", test.id, err, test.err)
+			continue
+		}
+		if err == nil && quote.ID != test.id {
+			t.Errorf("ByID(%s) = quote %s", test.id, quote.ID)
+		}
+	}
+}
+
+func TestSearchAddress(t *testing.T) {
+	scraper := NewScraper("http://bash.im/")
+	tests := []struct {
+		query string
+		page  int
+		want  string
+	}{
+		{"привет", 1, "http://bash.im/index?text=%EF%F0%E8%E2%E5%F2"},
+		{"Ёж и ёлка", 0, "http://bash.im/index?text=%A8%E6+%E8+%B8%EB%EA%E0"},
+		{"a&b=c", 1, "http://bash.im/index?text=a%26b%3Dc"},
+		{"кот", 3, "http://bash.im/index?text=%EA%EE%F2&page=3"},
+		{"🙂", 1, "http://bash.im/index?text=%1A"},
+	}
+	for _, test := range tests {
+		address, err := scraper.searchAddress(test.query, test.page)
+		if err != nil {
+			t.Errorf("searchAddress(%q) failed: %s", test.query, err)
+			continue
+		}
+		if address != test.want {
+			t.Errorf("searchAddress(%q, %d) = %s, want %s", test.query, test.page, address, test.want)
+		}
+	}
+}
+
+func TestScraperSearchPages(t *testing.T) {
+	// site repeats the last page for pages after it
+	pages := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		if page > len(pages) {
+			page = len(pages)
+		}
+		fmt.Fprint(w, "<html><body>")
+		for _, id := range pages[page-1] {
+			fmt.Fprintf(w, `<article class="quote" data-quote="%s"><div class="quote__body">quote %s</div></article>`, id, id)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		maxResults int
+		want       int
+	}{
+		{0, 5},
+		{100, 5},
+		{3, 3},
+	}
+	for _, test := range tests {
+		scraper := NewScraper(server.URL)
+		scraper.MaxResults = test.maxResults
+		quotes, err := scraper.Search("кот")
+		if err != nil {
+			t.Fatalf("Search failed: %s", err)
+		}
+		if len(quotes) != test.want {
+			t.Errorf("Search with %d results found %v, want %d quotes", test.maxResults, quotes, test.want)
+		}
+	}
+}
+
+func TestParseVoteAnswer(t *testing.T) {
+	tests := []struct {
+		body string
+		want VoteResult
+	}{
+		{"1507", VoteResult{Status: VoteAccepted, Rating: NewRating(1507)}},
+		{" -3\n", VoteResult{Status: VoteAccepted, Rating: NewRating(-3)}},
+		{`{"rating": 42}`, VoteResult{Status: VoteAccepted, Rating: NewRating(42)}},
+		{`{"quote": {"id": 1, "rating": -7}}`, VoteResult{Status: VoteAccepted, Rating: NewRating(-7)}},
+		{"Вы уже голосовали", VoteResult{Status: VoteRepeated}},
+		{"", VoteResult{Status: VoteAccepted}},
+		{"<html>ok</html>", VoteResult{Status: VoteAccepted}},
+		{`{"status": "ok"}`, VoteResult{Status: VoteAccepted}},
+	}
+	for _, test := range tests {
+		if got := parseVoteAnswer([]byte(test.body)); got != test.want {
+			t.Errorf("parseVoteAnswer(%q) = %+v, want %+v", test.body, got, test.want)
+		}
+	}
+}
+
+func TestScraperVote(t *testing.T) {
+	tests := []struct {
+		code   int
+		body   string
+		status VoteStatus
+		err    bool
+	}{
+		{http.StatusOK, "10", VoteAccepted, false},
+		{http.StatusConflict, "", VoteRepeated, false},
+		{http.StatusTooManyRequests, "", VoteRepeated, false},
+		{http.StatusForbidden, "", VoteRejected, false},
+		{http.StatusInternalServerError, "", VoteNetworkError, true},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.code)
+			fmt.Fprint(w, test.body)
+		}))
+
+		config := DefaultClientConfig()
+		config.Retries = 0
+		scraper := NewScraper(server.URL)
+		scraper.Client = NewClient(config)
+
+		result, err := scraper.Vote("1", VotePlus)
+		if result.Status != test.status || (err != nil) != test.err {
+			t.Errorf("vote answered with %d = %s, %v, want %s", test.code, result.Status, err, test.status)
+		}
+		server.Close()
+	}
+}
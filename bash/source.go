@@ -0,0 +1,193 @@
+package bash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+// Source errors
+var (
+	ErrNotFound    = errors.New("quote not found")
+	ErrPending     = errors.New("quote is pending in abyss")
+	ErrUnsupported = errors.New("not supported by source")
+)
+
+// Vote for quote
+type Vote int
+
+// Votes
+const (
+	VotePlus Vote = iota
+	VoteMinus
+	VoteBayan
+)
+
+// VoteStatus tells what happened to vote
+type VoteStatus int
+
+// Vote statuses
+const (
+	VoteAccepted VoteStatus = iota
+	VoteRepeated
+	VoteRejected
+	VoteNetworkError
+)
+
+var voteStatusNames = []string{"accepted", "repeated", "rejected", "network error"}
+
+func (s VoteStatus) String() string {
+	if s < VoteAccepted || s > VoteNetworkError {
+		return fmt.Sprintf("status(%d)", int(s))
+	}
+	return voteStatusNames[s]
+}
+
+// VoteResult is an answer of source to vote, Rating is known if source
+// returned new rating of quote
+type VoteResult struct {
+	Status VoteStatus
+	Rating Rating
+}
+
+var voteActs = map[Vote]string{
+	VotePlus:  "rulez",
+	VoteMinus: "sux",
+	VoteBayan: "bayan",
+}
+
+// Capabilities of source
+type Capabilities uint
+
+// Capabilities flags
+const (
+	CanRandom Capabilities = 1 << iota
+	CanSearch
+	CanByID
+	CanVote
+	// CanBrowse sources implement Browser
+	CanBrowse
+	// CanComics sources implement ComicsSource
+	CanComics
+)
+
+// Has reports whether all flags are set
+func (c Capabilities) Has(flags Capabilities) bool {
+	return c&flags == flags
+}
+
+// QuoteSource provides quotes
+type QuoteSource interface {
+	// Random returns some random quotes
+	Random() ([]Quote, error)
+	// Search returns quotes matching query
+	Search(query string) ([]Quote, error)
+	// ByID returns quote by id, ErrNotFound or ErrPending for quotes not approved yet
+	ByID(id string) (Quote, error)
+	// Vote sends vote for quote, error is returned along with VoteNetworkError
+	Vote(id string, vote Vote) (VoteResult, error)
+	// Capabilities tells which methods are supported
+	Capabilities() Capabilities
+}
+
+// Static is a QuoteSource over quotes kept in memory
+type Static struct {
+	quotes []Quote
+	byID   map[string]int
+}
+
+// NewStatic creates source of given quotes
+func NewStatic(quotes []Quote) *Static {
+	s := &Static{
+		quotes: quotes,
+		byID:   make(map[string]int, len(quotes)),
+	}
+	for i, quote := range quotes {
+		s.byID[quote.ID] = i
+	}
+	return s
+}
+
+// LoadStatic reads quotes from file with one JSON quote per line
+func LoadStatic(path string) (*Static, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	quotes, err := ReadQuotes(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read quotes from %q: %s", path, err)
+	}
+	return NewStatic(quotes), nil
+}
+
+// ReadQuotes reads JSON quotes, one per line
+func ReadQuotes(r io.Reader) ([]Quote, error) {
+	var quotes []Quote
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var quote Quote
+		if err := decoder.Decode(&quote); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// Quotes returns all quotes of source
+func (s *Static) Quotes() []Quote {
+	return s.quotes
+}
+
+// Random returns up to 50 random quotes
+func (s *Static) Random() ([]Quote, error) {
+	n := len(s.quotes)
+	if n > 50 {
+		n = 50
+	}
+
+	quotes := make([]Quote, n)
+	for i, j := range rand.Perm(len(s.quotes))[:n] {
+		quotes[i] = s.quotes[j]
+	}
+	return quotes, nil
+}
+
+// Search returns quotes which contain query, case is ignored
+func (s *Static) Search(query string) ([]Quote, error) {
+	query = strings.ToLower(query)
+
+	var quotes []Quote
+	for _, quote := range s.quotes {
+		if strings.Contains(strings.ToLower(quote.Text), query) {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+// ByID returns quote by id
+func (s *Static) ByID(id string) (Quote, error) {
+	i, ok := s.byID[id]
+	if !ok {
+		return Quote{}, ErrNotFound
+	}
+	return s.quotes[i], nil
+}
+
+// Vote is not supported
+func (s *Static) Vote(id string, vote Vote) (VoteResult, error) {
+	return VoteResult{Status: VoteRejected}, ErrUnsupported
+}
+
+// Capabilities of static source
+func (s *Static) Capabilities() Capabilities {
+	return CanRandom | CanSearch | CanByID
+}
@@ -3,28 +3,48 @@ package bot
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math/rand"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/crawler"
 	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
-	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
 	"github.com/AnisimoffNikita/go_bash_telgram_bot/pool"
 	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
 )
 
 // Bot struct
 type Bot struct {
-	API        telegram.BotAPI
+	API        Messenger
 	Pool       *pool.Pool
-	DB         *database.Tarantool
-	TimeOut    time.Duration
+	DB         database.Store
+	Source     bash.QuoteSource
+	Self       telegram.User
 	Processors map[string]func(update *telegram.Update) error
+	Commands   map[string]func(update *telegram.Update, args string) error
+
+	// telegram is set when bot talks to telegram, it receives updates
+	telegram *telegram.BotAPI
+	load     Loader
+	mu       sync.RWMutex
+	reloadMu sync.Mutex
+	config   Config
+	dbConfig database.Config
+	limiter  *rateLimiter
+	queues   *chatQueues
+	prefetch *bash.Prefetcher
+	shown    *shownQuotes
+	found    *chatCache
+	crawler  *crawler.Crawler
 }
 
 // Processors name
@@ -34,17 +54,38 @@ const (
 	SaveProcessor        = "save"
 	StartSearchProcessor = "startSearch"
 	SearchProcessor      = "search"
+	TopProcessor         = "top"
+	SectionsProcessor    = "sections"
+	BrowseProcessor      = "browse"
+	ComicsProcessor      = "comics"
 )
 
-func newBot(token string, timeout time.Duration, poolSize int) (*Bot, error) {
+// Messenger sends messages to chats
+type Messenger interface {
+	GetMe() (telegram.User, error)
+	SendText(chatID int, text string) (telegram.Message, error)
+	SendTextWithKeybord(chatID int, text string, keybord telegram.ReplyKeyboardMarkup) (telegram.Message, error)
+	SendTextWithoutKeybord(chatID int, text string) (telegram.Message, error)
+	EditMessageText(chatID int, messageID int, text string) (telegram.Message, error)
+	SendPhoto(chatID int, photo string, caption string, keybord *telegram.ReplyKeyboardMarkup) (telegram.Message, error)
+	UploadPhoto(chatID int, name string, data []byte, caption string, keybord *telegram.ReplyKeyboardMarkup) (telegram.Message, error)
+}
+
+// New creates bot which sends messages through api, keeps state in store
+// and takes quotes from source
+func New(api Messenger, store database.Store, source bash.QuoteSource, config Config) (*Bot, error) {
 	bot := &Bot{
-		API: telegram.BotAPI{
-			Token:  token,
-			Client: &http.Client{},
-		},
-		Pool:    pool.NewPool(poolSize),
-		TimeOut: timeout * time.Millisecond,
-	}
+		API:     api,
+		DB:      store,
+		Source:  source,
+		Pool:    pool.NewPool(config.PoolSize),
+		config:  config,
+		limiter: newRateLimiter(config.RateLimit, config.RateBurst),
+		shown:   newShownQuotes(),
+		found:   newChatCache(maxKeptChats),
+	}
+	bot.queues = newChatQueues(config.ChatQueueDepth)
+	bot.applyLogLevel(config)
 
 	bot.Processors = map[string]func(update *telegram.Update) error{
 		DefaultProcessor:     bot.processUpdate,
@@ -52,50 +93,115 @@ func newBot(token string, timeout time.Duration, poolSize int) (*Bot, error) {
 		StartSearchProcessor: bot.startSearch,
 		SearchProcessor:      bot.feedbackSearch,
 		SaveProcessor:        bot.feedbackSaved,
+		TopProcessor:         bot.feedbackTop,
+		SectionsProcessor:    bot.feedbackSections,
+		BrowseProcessor:      bot.feedbackBrowse,
+		ComicsProcessor:      bot.feedbackComics,
 	}
 
-	var err error
-	bot.DB, err = database.NewTarantool()
+	bot.Commands = map[string]func(update *telegram.Update, args string) error{
+		StartCommand:  bot.startCommand,
+		TopCommand:    bot.topCommand,
+		ReloadCommand: bot.reloadCommand,
+		QuoteCommand:  bot.quoteCommand,
+		DupesCommand:  bot.dupesCommand,
+		RatingCommand: bot.ratingCommand,
+	}
+
+	bot.Pool.Run()
+	registerPoolMetrics(bot.Pool)
 
+	self, err := bot.API.GetMe()
 	if err != nil {
 		return nil, err
 	}
 
-	bot.Pool.Run()
+	bot.Self = self
 
-	bot.DB.TruncateLastQuotes()
-	bot.DB.TruncateProcessor()
+	if config.PrefetchSize > 0 && source.Capabilities().Has(bash.CanRandom) {
+		bot.prefetch = bash.NewPrefetcher(source, config.PrefetchSize, config.PrefetchLowWater)
+		bot.prefetch.Start()
+		registerPrefetchMetrics(bot.prefetch)
+	}
+	return bot, nil
+}
 
-	self, err := bot.API.GetMe()
+func newBot(load Loader) (*Bot, error) {
+	config, dbConfig, err := load()
+	if err != nil {
+		return nil, err
+	}
+	logging.AddSecret(config.Token)
+
+	api := &telegram.BotAPI{
+		Token:  config.Token,
+		Client: &http.Client{},
+	}
+
+	db, err := database.NewTarantool(dbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := NewSource(config, db)
+	if err != nil {
+		return nil, err
+	}
+
+	bot, err := New(api, db, source, config)
 	if err != nil {
 		return nil, err
 	}
 
-	bot.API.Self = self
+	bot.telegram = api
+	bot.load = load
+	bot.dbConfig = dbConfig
+
+	bot.startCrawler(config)
 	return bot, nil
 }
 
-// StartBot begin bot work
-func StartBot() error {
-	var config Config
-	err := helper.GetYamlConfig(configPath, &config)
+// StartBot begin bot work, load is called on start and on every reload
+func StartBot(load Loader) error {
+	bot, err := newBot(load)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	bot, err := newBot(config.Token, config.TimeOut, config.PoolSize)
-	if err != nil {
-		log.Fatal(err)
+	logging.Infof("connected...")
+
+	go bot.watchReload()
+	config := bot.settings()
+
+	// serving is not stopped, background work is finished before exit
+	served := make(chan error, 1)
+	go func() {
+		served <- bot.serve(config)
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-served:
+		bot.Stop()
+		return err
+	case sig := <-signals:
+		logging.Infof("%s received, stopping...", sig)
+		bot.Stop()
+		return nil
 	}
+}
 
-	log.Println("connected...")
+// serve handles updates until error
+func (bot *Bot) serve(config Config) error {
+	if config.MetricsAddr != "" {
+		go bot.serveHealth(config.MetricsAddr)
+	}
 
-	defer bot.DB.TruncateLastQuotes()
-	defer bot.DB.TruncateProcessor()
-	defer bot.DB.TruncateSearch()
+	go bot.sweepSessions()
 
 	if config.PKey == "" {
-		log.Println("start...")
+		logging.Infof("start...")
 		return bot.processUpdatesChannel(config.PoolSize)
 	}
 
@@ -106,16 +212,16 @@ func StartBot() error {
 		config.PoolSize)
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	_, err = bot.setWebhook(&webhookConfig)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	log.Println("start...")
+	logging.Infof("start...")
 
-	http.HandleFunc("/"+bot.API.Token, bot.updateHandler)
+	http.HandleFunc("/"+bot.telegram.Token, bot.updateHandler)
 	return http.ListenAndServeTLS("0.0.0.0:"+config.Port,
 		config.Cert,
 		config.PKey,
@@ -129,27 +235,22 @@ func (bot *Bot) processUpdatesChannel(channelSize int) error {
 	}
 
 	for update := range updates {
-		go func(update *telegram.Update) {
-			res, err := bot.Pool.AddTaskSyncTimed(func() interface{} {
-				processor, err := bot.DB.GetProcessor(update.Message.Chat.ID)
-
-				if err != nil {
-					return bot.Processors[DefaultProcessor](update)
-				}
-				return bot.Processors[processor](update)
-			}, bot.TimeOut)
-
-			if res != nil {
-				log.Println(err)
-			}
-			if err != nil {
-				log.Println(err)
-			}
-		}(update)
+		bot.enqueue(update)
 	}
 	return nil
 }
 
+// runUpdate handles update in pool, handler reports its own result
+func (bot *Bot) runUpdate(update *telegram.Update) {
+	_, err := bot.Pool.AddTaskSyncTimed(func() interface{} {
+		return bot.HandleUpdate(update)
+	}, bot.settings().TimeOut)
+
+	if err != nil {
+		updateLogger(update).Errorf("update dropped: %s", err)
+	}
+}
+
 func (bot *Bot) getUpdatesChannel(poolSize int) (<-chan *telegram.Update, error) {
 	updatesChannel := make(chan *telegram.Update, poolSize)
 	offset := -1
@@ -163,16 +264,16 @@ func (bot *Bot) getUpdatesChannel(poolSize int) (<-chan *telegram.Update, error)
 				params.Add("offset", strconv.Itoa(offset))
 			}
 
-			resp, err := bot.API.MakeRequest("getUpdates", params)
+			resp, err := bot.telegram.MakeRequest("getUpdates", params)
 			if err != nil {
-				log.Println(err)
+				logging.Errorf("can't get updates: %s", err)
 				continue
 			}
 			var updates []*telegram.Update
 
 			err = json.Unmarshal(resp.Result, &updates)
 			if err != nil {
-				log.Println(err)
+				logging.Errorf("can't decode updates: %s", err)
 				continue
 			}
 			for _, v := range updates {
@@ -186,28 +287,109 @@ func (bot *Bot) getUpdatesChannel(poolSize int) (<-chan *telegram.Update, error)
 }
 
 func (bot *Bot) updateHandler(w http.ResponseWriter, r *http.Request) {
-	res, err := bot.Pool.AddTaskSyncTimed(func() interface{} {
-		bytes, _ := ioutil.ReadAll(r.Body)
+	var update telegram.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		logging.Warnf("can't decode update: %s", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	bot.enqueue(&update)
+}
+
+// HandleUpdate passes update to command or to current processor of chat
+func (bot *Bot) HandleUpdate(update *telegram.Update) error {
+	if update.Message == nil {
+		return telegram.ErrAPINoMessage
+	}
+
+	if !bot.limiter.allow(update.Message.Chat.ID) {
+		return bot.sendTooFast(update.Message.Chat.ID)
+	}
+
+	err := bot.DB.Touch(update.Message.Chat.ID)
+	if err != nil {
+		updateLogger(update).Warnf("can't touch session: %s", err)
+	}
+
+	name, f := bot.route(update)
+
+	start := time.Now()
+	err = f(update)
+	duration := time.Since(start)
+	observeUpdate(name, duration, err)
+
+	logger := updateLogger(update).With("processor", name, "duration", duration)
+	if err != nil {
+		logger.Errorf("update failed: %s", err)
+	} else {
+		logger.Debugf("update handled")
+	}
+
+	return err
+}
+
+// updateLogger returns logger with ids of update, chat and user
+func updateLogger(update *telegram.Update) *logging.Logger {
+	logger := logging.With("update_id", update.UpdateID)
+	if update.Message == nil {
+		return logger
+	}
+	if update.Message.Chat != nil {
+		logger = logger.With("chat_id", update.Message.Chat.ID)
+	}
+	if update.Message.From != nil {
+		logger = logger.With("user_id", update.Message.From.ID)
+	}
+	return logger
+}
 
-		var update telegram.Update
-		if err := json.Unmarshal(bytes, &update); err != nil {
-			return err
+// route returns name and handler of command, quote reference or current processor of chat
+func (bot *Bot) route(update *telegram.Update) (string, func(update *telegram.Update) error) {
+	if command, args, ok := parseCommand(update.Message.Text); ok {
+		if f, ok := bot.Commands[command]; ok {
+			return command, func(update *telegram.Update) error {
+				return f(update, args)
+			}
 		}
+	}
 
-		processor, err := bot.DB.GetProcessor(update.Message.Chat.ID)
-		if err != nil {
-			return bot.Processors[DefaultProcessor](&update)
+	processor, err := bot.DB.GetProcessor(update.Message.Chat.ID)
+	if err != nil {
+		processor = DefaultProcessor
+	}
+	f, ok := bot.Processors[processor]
+	if !ok {
+		processor, f = DefaultProcessor, bot.Processors[DefaultProcessor]
+	}
+
+	// while searching bare number is a query, quote is opened by "#12345" or link
+	searching := processor == StartSearchProcessor || processor == SearchProcessor
+	if searching && isBareNumber(update.Message.Text) {
+		return processor, f
+	}
+	if quoteID, ok := bot.parseQuoteRef(update.Message.Text); ok {
+		return QuoteCommand, func(update *telegram.Update) error {
+			return bot.openQuote(update.Message.Chat.ID, quoteID)
 		}
-		return bot.Processors[processor](&update)
+	}
+	return processor, f
+}
 
-	}, bot.TimeOut)
+// parseCommand splits "/command@bot args" into command and args
+func parseCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
 
-	if err != nil {
-		log.Println(err)
+	command, args := text, ""
+	if i := strings.IndexAny(text, " \n"); i >= 0 {
+		command, args = text[:i], strings.TrimSpace(text[i+1:])
 	}
-	if res != nil {
-		log.Println(err)
+	if i := strings.Index(command, "@"); i >= 0 {
+		command = command[:i]
 	}
+	return command, args, true
 }
 
 func (bot *Bot) setWebhook(webhookConfig *WebhookConfig) (telegram.APIResponse, error) {
@@ -215,7 +397,7 @@ func (bot *Bot) setWebhook(webhookConfig *WebhookConfig) (telegram.APIResponse,
 	params["url"] = webhookConfig.URL.String()
 	params["max_connections"] = strconv.Itoa(int(webhookConfig.PoolSize))
 
-	resp, err := bot.API.UploadFile("setWebhook", params, "certificate", webhookConfig.Cert)
+	resp, err := bot.telegram.UploadFile("setWebhook", params, "certificate", webhookConfig.Cert)
 
 	if err != nil {
 		return telegram.APIResponse{}, err
@@ -233,28 +415,36 @@ func (bot *Bot) processUpdate(update *telegram.Update) error {
 	text := update.Message.Text
 	id := update.Message.Chat.ID
 
-	if text == Start {
-		return bot.start(id, WhatSend)
-	} else if text == Random {
+	if text == Random {
 		return bot.sendRandom(id)
 	} else if text == Search {
 		return bot.sendSearch(id)
 	} else if text == Saved {
 		return bot.sendSaved(id)
+	} else if text == Sections && bot.Source.Capabilities().Has(bash.CanBrowse) {
+		return bot.sendSections(id)
+	} else if text == RandomComics && bot.Source.Capabilities().Has(bash.CanComics) {
+		return bot.sendRandomComic(id)
 	}
-	return bot.start(id, BadThing)
+	return bot.start(id, bot.messages().BadThing)
 }
 
 func (bot *Bot) start(id int, greeting string) error {
-	bot.DB.TruncateLastQuotes()
-	bot.DB.TruncateProcessor()
+	bot.DB.RemoveLastQuote(id)
 	bot.DB.SetProcessor(id, DefaultProcessor)
 
-	buttons := telegram.NewReplyKeyboardMarkup([][]string{
-		{Random},
-		{Search},
-		{Saved},
-	})
+	menu := [][]string{{Random}}
+	if bot.Source.Capabilities().Has(bash.CanBrowse) {
+		menu = append(menu, []string{Sections})
+	}
+	if bot.Source.Capabilities().Has(bash.CanSearch) {
+		menu = append(menu, []string{Search})
+	}
+	if bot.Source.Capabilities().Has(bash.CanComics) {
+		menu = append(menu, []string{RandomComics})
+	}
+	menu = append(menu, []string{Saved})
+	buttons := telegram.NewReplyKeyboardMarkup(menu)
 
 	_, err := bot.API.SendTextWithKeybord(id, greeting, buttons)
 	if err != nil {
@@ -263,21 +453,35 @@ func (bot *Bot) start(id int, greeting string) error {
 	return nil
 }
 
+// sendRandom sends quote from prefetch buffer and loads a page of quotes
+// only if buffer is empty, the rest of the page goes to the buffer
 func (bot *Bot) sendRandom(id int) error {
-	quotes, err := bash.GetQuotes("random")
+	if bot.prefetch != nil {
+		quote, err := bot.prefetch.Next()
+		if err == bash.ErrNotFound {
+			return bot.start(id, bot.messages().NothingToSend)
+		}
+		if err != nil {
+			return fmt.Errorf("can't get quotes: %s", err)
+		}
+		return bot.sendQuote(id, quote)
+	}
+
+	quotes, err := bot.Source.Random()
 	if err != nil {
 		return fmt.Errorf("can't get quotes: %s", err)
 	}
+	if len(quotes) == 0 {
+		return bot.start(id, bot.messages().NothingToSend)
+	}
+	return bot.sendQuote(id, quotes[rand.Intn(len(quotes))])
+}
 
-	buttons := telegram.NewReplyKeyboardMarkup([][]string{
-		{Other},
-		{Plus, Minus, Bayan},
-		{Back},
-	})
-
-	quote := quotes[rand.Intn(len(quotes))]
+// sendQuote sends quote with vote buttons, "Other" will send a random one
+func (bot *Bot) sendQuote(id int, quote bash.Quote) error {
+	bot.setLocalRating(&quote)
 
-	_, err = bot.API.SendTextWithKeybord(id, bash.QuoteToString(quote), buttons)
+	err := bot.sendQuoteMessage(id, quote, quoteButtons(bot.hasComic(quote)))
 	if err != nil {
 		return fmt.Errorf("can't send message %s", err)
 	}
@@ -294,6 +498,20 @@ func (bot *Bot) sendRandom(id int) error {
 	return nil
 }
 
+// quoteButtons returns vote buttons, comics adds button which sends comic of quote
+func quoteButtons(comics bool) telegram.ReplyKeyboardMarkup {
+	share := []string{Share}
+	if comics {
+		share = append(share, Comics)
+	}
+	return telegram.NewReplyKeyboardMarkup([][]string{
+		{Other},
+		{Plus, Minus, Bayan},
+		share,
+		{Back},
+	})
+}
+
 func (bot *Bot) feedbackQuote(update *telegram.Update) error {
 	if update.Message == nil {
 		return telegram.ErrAPINoMessage
@@ -313,21 +531,35 @@ func (bot *Bot) feedbackQuote(update *telegram.Update) error {
 		go func() {
 			err := bot.DB.SaveQuote(id, lastQuote)
 			if err != nil {
-				log.Printf("can't save quote: %s", err)
+				updateLogger(update).Errorf("can't save quote: %s", err)
+				return
+			}
+			bot.keepQuote(update, lastQuote)
+			userID, ok := senderID(update)
+			if !ok {
+				return
+			}
+			err = bot.DB.AddEvent(userID, lastQuote, database.EventSave, 1)
+			if err != nil {
+				updateLogger(update).Errorf("can't add event: %s", err)
 			}
 		}()
-		go bash.Plus(lastQuote)
+		bot.vote(update, lastQuote, database.VotePlus)
 		return bot.sendRandom(id)
 	case Minus:
-		go bash.Minus(lastQuote)
+		bot.vote(update, lastQuote, database.VoteMinus)
 		return bot.sendRandom(id)
 	case Bayan:
-		go bash.Bayan(lastQuote)
+		bot.vote(update, lastQuote, database.VoteBayan)
 		return bot.sendRandom(id)
+	case Share:
+		return bot.sendShare(id, lastQuote, "")
+	case Comics:
+		return bot.sendComic(id, lastQuote)
 	case Back:
-		return bot.start(id, WhatSend)
+		return bot.start(id, bot.messages().WhatSend)
 	default:
-		return bot.start(id, BadThing)
+		return bot.start(id, bot.messages().BadThing)
 	}
 }
 
@@ -336,7 +568,7 @@ func (bot *Bot) sendSearch(id int) error {
 	if err != nil {
 		return fmt.Errorf("can't set processor %s", err)
 	}
-	_, err = bot.API.SendTextWithoutKeybord(id, SearchReq)
+	_, err = bot.API.SendTextWithoutKeybord(id, bot.messages().SearchReq)
 	if err != nil {
 		return fmt.Errorf("can't send message %s", err)
 	}
@@ -349,8 +581,12 @@ func (bot *Bot) startSearch(update *telegram.Update) error {
 		return telegram.ErrAPINoMessage
 	}
 
-	text := update.Message.Text
-	id := update.Message.Chat.ID
+	return bot.beginSearch(update.Message.Chat.ID, update.Message.Text)
+}
+
+func (bot *Bot) beginSearch(id int, text string) error {
+	// new search is asked even if it repeats the previous one
+	bot.found.remove(id)
 
 	err := bot.DB.SetSearch(id, text, 0, "")
 	if err != nil {
@@ -382,40 +618,39 @@ func (bot *Bot) feedbackSearch(update *telegram.Update) error {
 	case Other:
 		return bot.sendFound(id, req, index)
 	case Plus:
-		go bash.Plus(quote)
+		bot.vote(update, quote, database.VotePlus)
 		return bot.sendFound(id, req, index)
 	case Minus:
-		go bash.Minus(quote)
+		bot.vote(update, quote, database.VoteMinus)
 		return bot.sendFound(id, req, index)
 	case Bayan:
-		go bash.Bayan(quote)
+		bot.vote(update, quote, database.VoteBayan)
 		return bot.sendFound(id, req, index)
+	case Share:
+		return bot.sendShare(id, quote, req)
+	case Comics:
+		return bot.sendComic(id, quote)
 	case Back:
-		return bot.start(id, WhatSend)
+		return bot.start(id, bot.messages().WhatSend)
 	default:
-		return bot.start(id, BadThing)
+		return bot.start(id, bot.messages().BadThing)
 	}
 }
 
 func (bot *Bot) sendFound(id int, text string, index int) error {
 
-	quotes, err := bash.Search(text)
+	quotes, err := bot.searchQuotes(id, text)
 	if err != nil {
 		return fmt.Errorf("can't search message: %s", err)
 	}
 
-	buttons := telegram.NewReplyKeyboardMarkup([][]string{
-		{Other},
-		{Plus, Minus, Bayan},
-		{Back},
-	})
-
 	if len(quotes) == 0 || len(quotes) <= index {
-		return bot.start(id, NothingToSend)
+		return bot.start(id, bot.messages().NothingToSend)
 	}
 
 	quote := quotes[index]
-	_, err = bot.API.SendTextWithKeybord(id, bash.QuoteToString(quote), buttons)
+	bot.setLocalRating(&quote)
+	err = bot.sendQuoteMessage(id, quote, quoteButtons(bot.hasComic(quote)))
 	if err != nil {
 		return fmt.Errorf("can't send message %s", err)
 	}
@@ -430,22 +665,16 @@ func (bot *Bot) sendFound(id int, text string, index int) error {
 func (bot *Bot) sendSaved(id int) error {
 	quotes, err := bot.DB.GetSavedQuotes(id)
 	if err == database.ErrEmpty {
-		return bot.start(id, NothingToSend)
+		return bot.start(id, bot.messages().NothingToSend)
 	}
 
 	if err != nil {
 		return fmt.Errorf("sendSaved error: %s", err)
 	}
 
-	buttons := telegram.NewReplyKeyboardMarkup([][]string{
-		{Other},
-		{Delete},
-		{Back},
-	})
-
 	l := len(quotes)
 	if l <= 0 {
-		return bot.start(id, NothingToSend)
+		return bot.start(id, bot.messages().NothingToSend)
 	}
 	n := rand.Intn(l)
 	i := 0
@@ -458,12 +687,27 @@ func (bot *Bot) sendSaved(id int) error {
 		i++
 	}
 
-	quote, err := bash.GetQuoteByID(quoteID)
+	quote, err := bot.DB.GetQuote(quoteID)
+	if err != nil {
+		quote, err = bot.Source.ByID(quoteID)
+	}
 	if err != nil {
 		return fmt.Errorf("can't get quote by id: %s", err)
 	}
+	bot.setLocalRating(&quote)
+
+	share := []string{Share}
+	if bot.hasComic(quote) {
+		share = append(share, Comics)
+	}
+	buttons := telegram.NewReplyKeyboardMarkup([][]string{
+		{Other},
+		{Delete},
+		share,
+		{Back},
+	})
 
-	_, err = bot.API.SendTextWithKeybord(id, bash.QuoteToString(quote), buttons)
+	err = bot.sendQuoteMessage(id, quote, buttons)
 	if err != nil {
 		return fmt.Errorf("can't send message: %s", err)
 	}
@@ -502,10 +746,128 @@ func (bot *Bot) feedbackSaved(update *telegram.Update) error {
 		if err != nil {
 			return fmt.Errorf("can't save quote: %s", err)
 		}
+		if userID, ok := senderID(update); ok {
+			err = bot.DB.RemoveEvent(userID, lastQuote, database.EventSave)
+			if err != nil {
+				updateLogger(update).Errorf("can't remove event: %s", err)
+			}
+		}
 		return bot.sendSaved(id)
+	case Share:
+		return bot.sendShare(id, lastQuote, "")
+	case Comics:
+		return bot.sendComic(id, lastQuote)
 	case Back:
-		return bot.start(id, WhatSend)
+		return bot.start(id, bot.messages().WhatSend)
 	default:
-		return bot.start(id, BadThing)
+		return bot.start(id, bot.messages().BadThing)
+	}
+}
+
+// sourceVotes maps stored votes to votes of quote source
+var sourceVotes = map[int]bash.Vote{
+	database.VotePlus:  bash.VotePlus,
+	database.VoteMinus: bash.VoteMinus,
+	database.VoteBayan: bash.VoteBayan,
+}
+
+// vote counts vote of user and sends it to source in background, then
+// result is shown in the message of quote if it is still known. Votes of
+// unknown senders are not counted.
+func (bot *Bot) vote(update *telegram.Update, quoteID string, vote int) {
+	userID, ok := senderID(update)
+	if !ok {
+		return
+	}
+
+	chatID := update.Message.Chat.ID
+	shown, ok := bot.shown.get(chatID)
+	ok = ok && shown.quote.ID == quoteID
+
+	go func() {
+		result := bot.sendVote(update, userID, quoteID, vote)
+		if ok {
+			bot.showVote(chatID, shown, result)
+		}
+	}()
+}
+
+func (bot *Bot) sendVote(update *telegram.Update, userID int, quoteID string, vote int) bash.VoteResult {
+	changed, err := bot.DB.SetVote(userID, quoteID, vote)
+	if err != nil {
+		updateLogger(update).Errorf("can't set vote: %s", err)
+		return bash.VoteResult{Status: bash.VoteRejected}
+	}
+	if !changed {
+		return bash.VoteResult{Status: bash.VoteRepeated}
+	}
+
+	err = bot.DB.AddEvent(userID, quoteID, database.EventVote, vote)
+	if err != nil {
+		updateLogger(update).Errorf("can't add event: %s", err)
+	}
+
+	if !bot.Source.Capabilities().Has(bash.CanVote) {
+		return bash.VoteResult{Status: bash.VoteAccepted}
+	}
+
+	result, err := bot.Source.Vote(quoteID, sourceVotes[vote])
+	if err != nil {
+		updateLogger(update).Warnf("can't send vote: %s", err)
+	}
+	updateLogger(update).With("quote_id", quoteID, "status", result.Status).Debugf("vote sent")
+	return result
+}
+
+// showVote edits message of quote to show new rating and result of vote
+func (bot *Bot) showVote(chatID int, shown shownQuote, result bash.VoteResult) {
+	quote := shown.quote
+	if result.Rating.Known {
+		quote.Rating = result.Rating
+	}
+	bot.setLocalRating(&quote)
+
+	messages := bot.messages()
+	status := map[bash.VoteStatus]string{
+		bash.VoteAccepted:     messages.VoteAccepted,
+		bash.VoteRepeated:     messages.VoteRepeated,
+		bash.VoteRejected:     messages.VoteRejected,
+		bash.VoteNetworkError: messages.VoteFailed,
+	}[result.Status]
+
+	_, err := bot.API.EditMessageText(chatID, shown.messageID, bot.quoteText(quote)+"\n"+status)
+	if err != nil {
+		logging.With("chat_id", chatID, "quote_id", quote.ID).Warnf("can't show vote: %s", err)
+	}
+}
+
+// keepQuote stores saved quote, so it is shown even if source loses it
+func (bot *Bot) keepQuote(update *telegram.Update, quoteID string) {
+	quote, err := bot.Source.ByID(quoteID)
+	if err != nil {
+		updateLogger(update).Warnf("can't get saved quote %s: %s", quoteID, err)
+		return
+	}
+	err = bot.DB.PutQuote(quote)
+	if err != nil {
+		updateLogger(update).Errorf("can't keep saved quote: %s", err)
+	}
+}
+
+func (bot *Bot) setLocalRating(quote *bash.Quote) {
+	rating, err := bot.DB.GetRating(quote.ID)
+	if err != nil {
+		logging.With("quote_id", quote.ID).Warnf("can't get rating: %s", err)
+		return
+	}
+	quote.LocalRating = rating
+}
+
+// senderID returns id of user who sent update, it is unknown for messages
+// sent on behalf of channels
+func senderID(update *telegram.Update) (int, bool) {
+	if update.Message.From == nil {
+		return 0, false
 	}
+	return update.Message.From.ID, true
 }
@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+var listingTitles = map[string]string{
+	bash.ListingNew:       "Новые",
+	bash.ListingBestDay:   "Лучшие за день",
+	bash.ListingBestMonth: "Лучшие за месяц",
+	bash.ListingBestYear:  "Лучшие за год",
+	bash.ListingByRating:  "По рейтингу",
+	bash.ListingAbyss:     "Бездна",
+	bash.ListingAbyssTop:  "Топ Бездны",
+	bash.ListingAbyssBest: "Лучшие Бездны",
+}
+
+func (bot *Bot) sendSections(id int) error {
+	menu := make([][]string, 0, len(bash.Listings)/2+2)
+	for i := 0; i < len(bash.Listings); i += 2 {
+		row := []string{listingTitles[bash.Listings[i]]}
+		if i+1 < len(bash.Listings) {
+			row = append(row, listingTitles[bash.Listings[i+1]])
+		}
+		menu = append(menu, row)
+	}
+	menu = append(menu, []string{Back})
+
+	_, err := bot.API.SendTextWithKeybord(id, bot.messages().ChooseSection, telegram.NewReplyKeyboardMarkup(menu))
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+
+	err = bot.DB.SetProcessor(id, SectionsProcessor)
+	if err != nil {
+		return fmt.Errorf("can't set processor: %s", err)
+	}
+	return nil
+}
+
+func (bot *Bot) feedbackSections(update *telegram.Update) error {
+	if update.Message == nil {
+		return fmt.Errorf("feedbackSections error: %s", telegram.ErrAPINoMessage)
+	}
+
+	text := update.Message.Text
+	id := update.Message.Chat.ID
+
+	if text == Back {
+		return bot.start(id, bot.messages().WhatSend)
+	}
+	for listing, title := range listingTitles {
+		if text == title {
+			page, err := bot.loadPage(listing, "")
+			if err != nil {
+				return err
+			}
+			return bot.moveBrowse(id, listing, page, 0)
+		}
+	}
+	return bot.start(id, bot.messages().BadThing)
+}
+
+// loadPage fetches page of listing from source
+func (bot *Bot) loadPage(listing string, token string) (bash.Page, error) {
+	browser, ok := bot.Source.(bash.Browser)
+	if !ok || !bot.Source.Capabilities().Has(bash.CanBrowse) {
+		return bash.Page{}, bash.ErrUnsupported
+	}
+
+	page, err := browser.Browse(listing, token)
+	if err != nil {
+		return bash.Page{}, fmt.Errorf("can't browse %s: %s", listing, err)
+	}
+	return page, nil
+}
+
+// browsedPage returns page shown in chat and index of last shown quote on it,
+// page is fetched again only if bot does not keep it, for example after
+// restart. Reloaded page may differ from shown one (random listings always
+// do), then index is found by quoteID or set before the first quote
+func (bot *Bot) browsedPage(id int, listing string, token string, index int, quoteID string) (bash.Page, int, error) {
+	if shown, ok := bot.shown.get(id); ok && shown.page != nil && shown.listing == listing && shown.page.Token == token {
+		return *shown.page, index, nil
+	}
+
+	page, err := bot.loadPage(listing, token)
+	if err != nil {
+		return bash.Page{}, 0, err
+	}
+	if index >= 0 && index < len(page.Quotes) && page.Quotes[index].ID == quoteID {
+		return page, index, nil
+	}
+	for i, quote := range page.Quotes {
+		if quote.ID == quoteID {
+			return page, i, nil
+		}
+	}
+	return page, -1, nil
+}
+
+// moveBrowse sends quote at index of page, index out of page moves to
+// neighbour page, random listings load new quotes instead
+func (bot *Bot) moveBrowse(id int, listing string, page bash.Page, index int) error {
+	var err error
+	switch {
+	case index >= 0 && index < len(page.Quotes):
+	case index < 0 && page.Prev != "":
+		page, err = bot.loadPage(listing, page.Prev)
+		index = len(page.Quotes) - 1
+	case index < 0:
+		index = 0
+	case page.Next != "":
+		page, err = bot.loadPage(listing, page.Next)
+		index = 0
+	case page.Random:
+		page, err = bot.loadPage(listing, "")
+		index = 0
+	}
+	if err != nil {
+		return err
+	}
+	if index < 0 || index >= len(page.Quotes) {
+		return bot.start(id, bot.messages().NothingToSend)
+	}
+	return bot.sendBrowse(id, listing, page, index)
+}
+
+func (bot *Bot) sendBrowse(id int, listing string, page bash.Page, index int) error {
+	quote := page.Quotes[index]
+	bot.setLocalRating(&quote)
+	err := bot.sendQuoteMessage(id, quote, browseButtons(page, index, bot.hasComic(quote)))
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	bot.shown.setPage(id, listing, page)
+
+	err = bot.DB.SetBrowse(id, listing, page.Token, index)
+	if err != nil {
+		return fmt.Errorf("can't set browse: %s", err)
+	}
+	err = bot.DB.SetProcessor(id, BrowseProcessor)
+	if err != nil {
+		return fmt.Errorf("can't set processor: %s", err)
+	}
+	err = bot.DB.SetLastQuote(id, quote.ID)
+	if err != nil {
+		return fmt.Errorf("can't set quote: %s", err)
+	}
+	return nil
+}
+
+func (bot *Bot) feedbackBrowse(update *telegram.Update) error {
+	if update.Message == nil {
+		return fmt.Errorf("feedbackBrowse error: %s", telegram.ErrAPINoMessage)
+	}
+
+	text := update.Message.Text
+	id := update.Message.Chat.ID
+
+	if text == Back {
+		return bot.sendSections(id)
+	}
+
+	listing, token, index, err := bot.DB.GetBrowse(id)
+	if err != nil {
+		return fmt.Errorf("can't get browse: %s", err)
+	}
+	quote, err := bot.DB.GetLastQuote(id)
+	if err != nil {
+		return fmt.Errorf("can't get quote: %s", err)
+	}
+	page, index, err := bot.browsedPage(id, listing, token, index, quote)
+	if err != nil {
+		return err
+	}
+
+	switch text {
+	case Next:
+		return bot.moveBrowse(id, listing, page, index+1)
+	case Prev:
+		return bot.moveBrowse(id, listing, page, index-1)
+	case NextPage, PrevPage:
+		token := page.Next
+		if text == PrevPage {
+			token = page.Prev
+		}
+		if token == "" {
+			return bot.start(id, bot.messages().BadThing)
+		}
+		page, err := bot.loadPage(listing, token)
+		if err != nil {
+			return err
+		}
+		return bot.moveBrowse(id, listing, page, 0)
+	case Plus:
+		bot.vote(update, quote, database.VotePlus)
+		return bot.moveBrowse(id, listing, page, index+1)
+	case Minus:
+		bot.vote(update, quote, database.VoteMinus)
+		return bot.moveBrowse(id, listing, page, index+1)
+	case Bayan:
+		bot.vote(update, quote, database.VoteBayan)
+		return bot.moveBrowse(id, listing, page, index+1)
+	case Share:
+		return bot.sendShare(id, quote, "")
+	case Comics:
+		return bot.sendComic(id, quote)
+	default:
+		return bot.start(id, bot.messages().BadThing)
+	}
+}
+
+// browseButtons shows page buttons only for listings which have pages,
+// random listings have no neighbour pages
+func browseButtons(page bash.Page, index int, comics bool) telegram.ReplyKeyboardMarkup {
+	quotes := make([]string, 0, 2)
+	if index > 0 || page.Prev != "" {
+		quotes = append(quotes, Prev)
+	}
+	quotes = append(quotes, Next)
+
+	pages := make([]string, 0, 2)
+	if page.Prev != "" {
+		pages = append(pages, PrevPage)
+	}
+	if page.Next != "" {
+		pages = append(pages, NextPage)
+	}
+
+	menu := [][]string{quotes}
+	if len(pages) > 0 {
+		menu = append(menu, pages)
+	}
+	share := []string{Share}
+	if comics {
+		share = append(share, Comics)
+	}
+	menu = append(menu, []string{Plus, Minus, Bayan}, share, []string{Back})
+	return telegram.NewReplyKeyboardMarkup(menu)
+}
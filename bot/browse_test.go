@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// pagesSource browses fixed pages by token
+type pagesSource struct {
+	bash.QuoteSource
+	pages map[string]bash.Page
+}
+
+func (s pagesSource) Capabilities() bash.Capabilities {
+	return bash.CanBrowse
+}
+
+func (s pagesSource) Browse(listing string, token string) (bash.Page, error) {
+	return s.pages[token], nil
+}
+
+func testPage(token string, random bool, ids ...string) bash.Page {
+	page := bash.Page{Token: token, Random: random}
+	for _, id := range ids {
+		page.Quotes = append(page.Quotes, bash.Quote{ID: id})
+	}
+	return page
+}
+
+func TestBrowsedPage(t *testing.T) {
+	source := pagesSource{pages: map[string]bash.Page{
+		"":   testPage("", true, "7", "8", "9"),
+		"p2": testPage("p2", false, "4", "5", "6"),
+	}}
+
+	tests := []struct {
+		name    string
+		token   string
+		index   int
+		quoteID string
+		want    int
+	}{
+		{"same page", "p2", 1, "5", 1},
+		{"quote moved", "p2", 0, "6", 2},
+		{"quote gone", "p2", 2, "3", -1},
+		{"random page", "", 1, "2", -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bot := &Bot{Source: source, shown: newShownQuotes()}
+			_, index, err := bot.browsedPage(1, bash.ListingNew, test.token, test.index, test.quoteID)
+			if err != nil {
+				t.Fatalf("browsedPage failed: %s", err)
+			}
+			if index != test.want {
+				t.Errorf("index = %d, want %d", index, test.want)
+			}
+		})
+	}
+
+	bot := &Bot{Source: source, shown: newShownQuotes()}
+	kept := testPage("", true, "1", "2")
+	bot.shown.set(1, shownQuote{quote: kept.Quotes[1], listing: bash.ListingAbyss, page: &kept})
+	shown, index, err := bot.browsedPage(1, bash.ListingAbyss, "", 1, "2")
+	if err != nil || index != 1 || shown.Quotes[0].ID != "1" {
+		t.Errorf("kept page is not used: %v, %d, %v", shown.Quotes, index, err)
+	}
+}
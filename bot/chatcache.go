@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxKeptChats limits number of chats whose state is kept in memory
+const maxKeptChats = 10000
+
+// chatCache keeps a value per chat in memory, values of the least recently
+// used chats are dropped when there are more than size of them
+type chatCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[int]*list.Element
+}
+
+type chatEntry struct {
+	chatID int
+	value  interface{}
+}
+
+func newChatCache(size int) *chatCache {
+	return &chatCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[int]*list.Element),
+	}
+}
+
+func (c *chatCache) get(chatID int) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.items[chatID]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*chatEntry).value, true
+}
+
+func (c *chatCache) set(chatID int, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[chatID]; ok {
+		element.Value.(*chatEntry).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[chatID] = c.order.PushFront(&chatEntry{chatID: chatID, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*chatEntry).chatID)
+	}
+}
+
+// update replaces value of chat by result of f, unknown chat is left as is
+func (c *chatCache) update(chatID int, f func(value interface{}) interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[chatID]; ok {
+		entry := element.Value.(*chatEntry)
+		entry.value = f(entry.value)
+	}
+}
+
+func (c *chatCache) remove(chatID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[chatID]; ok {
+		c.order.Remove(element)
+		delete(c.items, chatID)
+	}
+}
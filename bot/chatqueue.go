@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"sync"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+var droppedUpdates = metrics.NewCounter("bot_updates_dropped_total",
+	"Updates dropped because queue of chat was full")
+
+// chatQueues runs tasks of one chat, its updates and expiry of its session,
+// strictly in order, different chats run in parallel. Worker of chat exits
+// when its queue is empty.
+type chatQueues struct {
+	mu     sync.Mutex
+	depth  int
+	queues map[int]chan func()
+}
+
+func newChatQueues(depth int) *chatQueues {
+	return &chatQueues{
+		depth:  depth,
+		queues: make(map[int]chan func()),
+	}
+}
+
+// setDepth changes depth of queues created after the call
+func (q *chatQueues) setDepth(depth int) {
+	q.mu.Lock()
+	q.depth = depth
+	q.mu.Unlock()
+}
+
+// push adds task to queue of chat, it returns false if the queue is full
+func (q *chatQueues) push(chatID int, task func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue, ok := q.queues[chatID]
+	if !ok {
+		queue = make(chan func(), q.depth)
+		q.queues[chatID] = queue
+		go q.work(chatID, queue)
+	}
+
+	select {
+	case queue <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *chatQueues) work(chatID int, queue chan func()) {
+	for {
+		q.mu.Lock()
+		select {
+		case task := <-queue:
+			q.mu.Unlock()
+			task()
+		default:
+			delete(q.queues, chatID)
+			q.mu.Unlock()
+			return
+		}
+	}
+}
+
+// enqueue passes update to queue of its chat, overflow is dropped
+func (bot *Bot) enqueue(update *telegram.Update) {
+	chatID := 0
+	if update.Message != nil && update.Message.Chat != nil {
+		chatID = update.Message.Chat.ID
+	}
+
+	if bot.queues.push(chatID, func() { bot.runUpdate(update) }) {
+		return
+	}
+
+	droppedUpdates.Inc()
+	updateLogger(update).Warnf("queue of chat is full, update dropped")
+	if chatID != 0 {
+		go bot.sendTooFast(chatID)
+	}
+}
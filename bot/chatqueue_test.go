@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChatQueuesOrder(t *testing.T) {
+	queues := newChatQueues(100)
+
+	var mu sync.Mutex
+	got := make(map[int][]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for chatID := 1; chatID <= 3; chatID++ {
+			chatID, i := chatID, i
+			wg.Add(1)
+			ok := queues.push(chatID, func() {
+				defer wg.Done()
+				mu.Lock()
+				got[chatID] = append(got[chatID], i)
+				mu.Unlock()
+			})
+			if !ok {
+				t.Fatalf("task %d of chat %d is dropped", i, chatID)
+			}
+		}
+	}
+	wg.Wait()
+
+	for chatID, order := range got {
+		for i, n := range order {
+			if n != i {
+				t.Fatalf("chat %d ran tasks in order %v", chatID, order)
+			}
+		}
+	}
+}
+
+func TestChatQueuesOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		push  int
+		want  int
+	}{
+		{"fits", 3, 3, 3},
+		{"overflow", 2, 5, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queues := newChatQueues(test.depth)
+
+			// first task blocks worker so that the rest wait in queue
+			started := make(chan struct{})
+			release := make(chan struct{})
+			queues.push(1, func() {
+				close(started)
+				<-release
+			})
+			<-started
+
+			pushed := 0
+			for i := 0; i < test.push; i++ {
+				if queues.push(1, func() {}) {
+					pushed++
+				}
+			}
+			if pushed != test.want {
+				t.Errorf("pushed %d tasks, want %d", pushed, test.want)
+			}
+			if !queues.push(2, func() {}) {
+				t.Error("task of other chat is dropped")
+			}
+			close(release)
+		})
+	}
+}
+
+func TestChatQueuesWorkerExits(t *testing.T) {
+	queues := newChatQueues(1)
+	done := make(chan struct{})
+	queues.push(1, func() { close(done) })
+	<-done
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		queues.mu.Lock()
+		n := len(queues.queues)
+		queues.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d queues are left", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// comicsSource returns source of comics wrapped by source if there is one
+func comicsSource(source bash.QuoteSource) (bash.ComicsSource, bool) {
+	if !source.Capabilities().Has(bash.CanComics) {
+		return nil, false
+	}
+	for _, s := range sourceChain(source) {
+		if comics, ok := s.(bash.ComicsSource); ok {
+			return comics, true
+		}
+	}
+	return nil, false
+}
+
+func (bot *Bot) hasComic(quote bash.Quote) bool {
+	_, ok := comicsSource(bot.Source)
+	return ok && quote.Comics != ""
+}
+
+// sendComic sends comic of quote, keyboard of chat is kept
+func (bot *Bot) sendComic(id int, quoteID string) error {
+	comics, ok := comicsSource(bot.Source)
+	if !ok {
+		return bot.start(id, bot.messages().BadThing)
+	}
+
+	quote, err := bot.comicQuote(id, quoteID)
+	if err != nil {
+		return fmt.Errorf("can't get quote %s: %s", quoteID, err)
+	}
+
+	comic, err := comics.Comic(quote)
+	if err == bash.ErrNotFound {
+		_, err = bot.API.SendText(id, bot.messages().NoComic)
+		if err != nil {
+			return fmt.Errorf("can't send message: %s", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("can't get comic of %s: %s", quoteID, err)
+	}
+
+	return bot.sendPhoto(id, comics, comic, bot.comicCaption(comic), nil)
+}
+
+// comicQuote returns shown quote if it is the asked one, so page of
+// quote is not loaded again
+func (bot *Bot) comicQuote(id int, quoteID string) (bash.Quote, error) {
+	if shown, ok := bot.shown.get(id); ok && shown.quote.ID == quoteID {
+		return shown.quote, nil
+	}
+	if quote, err := bot.DB.GetQuote(quoteID); err == nil {
+		return quote, nil
+	}
+	return bot.Source.ByID(quoteID)
+}
+
+func (bot *Bot) sendRandomComic(id int) error {
+	comics, ok := comicsSource(bot.Source)
+	if !ok {
+		return bot.start(id, bot.messages().BadThing)
+	}
+
+	list, err := comics.RandomComics()
+	if err != nil {
+		return fmt.Errorf("can't get comics: %s", err)
+	}
+	if len(list) == 0 {
+		return bot.start(id, bot.messages().NothingToSend)
+	}
+	comic := list[rand.Intn(len(list))]
+
+	buttons := telegram.NewReplyKeyboardMarkup([][]string{
+		{OtherComic},
+		{ToQuote},
+		{Back},
+	})
+	err = bot.sendPhoto(id, comics, comic, bot.comicCaption(comic), &buttons)
+	if err != nil {
+		return err
+	}
+
+	err = bot.DB.SetProcessor(id, ComicsProcessor)
+	if err != nil {
+		return fmt.Errorf("can't set processor: %s", err)
+	}
+	err = bot.DB.SetLastQuote(id, comic.QuoteID)
+	if err != nil {
+		return fmt.Errorf("can't set quote: %s", err)
+	}
+	return nil
+}
+
+func (bot *Bot) feedbackComics(update *telegram.Update) error {
+	if update.Message == nil {
+		return fmt.Errorf("feedbackComics error: %s", telegram.ErrAPINoMessage)
+	}
+
+	text := update.Message.Text
+	id := update.Message.Chat.ID
+
+	switch text {
+	case OtherComic:
+		return bot.sendRandomComic(id)
+	case ToQuote:
+		quoteID, err := bot.DB.GetLastQuote(id)
+		if err != nil {
+			return fmt.Errorf("can't get quote: %s", err)
+		}
+		return bot.openQuote(id, quoteID)
+	case Back:
+		return bot.start(id, bot.messages().WhatSend)
+	default:
+		return bot.start(id, bot.messages().BadThing)
+	}
+}
+
+// comicCaption names quote of comic and gives link which opens it
+func (bot *Bot) comicCaption(comic bash.Comic) string {
+	return fmt.Sprintf(bot.messages().ComicCaption, comic.QuoteID) + "\n" + bot.quoteLink(comic.QuoteID)
+}
+
+// sendPhoto lets telegram download picture itself and uploads it when
+// telegram can't reach the site
+func (bot *Bot) sendPhoto(id int, comics bash.ComicsSource, comic bash.Comic, caption string, buttons *telegram.ReplyKeyboardMarkup) error {
+	_, err := bot.API.SendPhoto(id, comic.Image, caption, buttons)
+	if err == nil {
+		return nil
+	}
+	logging.With("chat_id", id, "quote_id", comic.QuoteID).Debugf("can't send comic by address: %s", err)
+
+	data, err := comics.Download(comic.Image)
+	if err != nil {
+		return fmt.Errorf("can't get comic: %s", err)
+	}
+	_, err = bot.API.UploadPhoto(id, path.Base(comic.Image), data, caption, buttons)
+	if err != nil {
+		return fmt.Errorf("can't send comic: %s", err)
+	}
+	return nil
+}
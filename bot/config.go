@@ -1,28 +1,283 @@
 package bot
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/dupes"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
 )
 
+// Config sources
 const (
-	configPath = "config.yml"
+	ConfigPath = "config.yml"
+	EnvPrefix  = "BASHBOT_"
+
+	// IndexPath is a default file of search index
+	IndexPath = "search_index.jsonl"
 )
 
 // Config of bot
 type Config struct {
-	Token    string        `yaml:"token"`
-	Cert     string        `yaml:"cert"`
-	PKey     string        `yaml:"pkey"`
-	Host     string        `yaml:"host"`
-	Port     string        `yaml:"port"`
-	PoolSize int           `yaml:"pool_size"`
-	TimeOut  time.Duration `yaml:"timeout"`
+	Token     string        `yaml:"token"`
+	TokenFile string        `yaml:"token_file"`
+	Cert      string        `yaml:"cert"`
+	PKey      string        `yaml:"pkey"`
+	Host      string        `yaml:"host"`
+	Port      string        `yaml:"port"`
+	PoolSize  int           `yaml:"pool_size"`
+	TimeOut   time.Duration `yaml:"timeout"`
+	Admins    []int         `yaml:"admins"`
+	RateLimit float64       `yaml:"rate_limit"`
+	RateBurst int           `yaml:"rate_burst"`
+	LogLevel  string        `yaml:"log_level"`
+	LogFormat string        `yaml:"log_format"`
+	Messages  Messages      `yaml:"messages"`
+
+	// Debug is read from configs of older versions, it means log_level debug
+	Debug bool `yaml:"debug"`
+
+	ChatQueueDepth int           `yaml:"chat_queue_depth"`
+	MetricsAddr    string        `yaml:"metrics_addr"`
+	FetchMaxAge    time.Duration `yaml:"fetch_max_age"`
+	SessionTTL     time.Duration `yaml:"session_ttl"`
+
+	Source     string `yaml:"source"`
+	SourceURL  string `yaml:"source_url"`
+	SourceFile string `yaml:"source_file"`
+
+	SourceConnectTimeout  time.Duration `yaml:"source_connect_timeout"`
+	SourceReadTimeout     time.Duration `yaml:"source_read_timeout"`
+	SourceRetries         int           `yaml:"source_retries"`
+	SourceUserAgent       string        `yaml:"source_user_agent"`
+	SearchMaxResults      int           `yaml:"search_max_results"`
+	SearchIndex           bool          `yaml:"search_index"`
+	SearchIndexFile       string        `yaml:"search_index_file"`
+	SearchIndexMinResults int           `yaml:"search_index_min_results"`
+	Dupes                 bool          `yaml:"dupes"`
+	DupesThreshold        float64       `yaml:"dupes_threshold"`
+	DupesSize             int           `yaml:"dupes_size"`
+
+	CacheSize    int           `yaml:"cache_size"`
+	CacheTTL     time.Duration `yaml:"cache_ttl"`
+	CachePersist bool          `yaml:"cache_persist"`
+
+	PrefetchSize     int `yaml:"prefetch_size"`
+	PrefetchLowWater int `yaml:"prefetch_low_water"`
+
+	CrawlInterval time.Duration `yaml:"crawl_interval"`
+	CrawlDelay    time.Duration `yaml:"crawl_delay"`
+	CrawlPages    int           `yaml:"crawl_pages"`
+	CrawlListings []string      `yaml:"crawl_listings"`
+}
+
+// DefaultConfig returns config with default values
+func DefaultConfig() Config {
+	return Config{
+		Port:      "8443",
+		PoolSize:  4,
+		TimeOut:   10 * time.Second,
+		RateBurst: 1,
+		LogLevel:  "info",
+		LogFormat: logging.LogfmtFormat,
+		Messages:  DefaultMessages(),
+
+		ChatQueueDepth: 16,
+		FetchMaxAge:    10 * time.Minute,
+		SessionTTL:     24 * time.Hour,
+
+		Source:    BashSource,
+		SourceURL: bash.DefaultURL,
+
+		SourceConnectTimeout:  bash.DefaultClientConfig().ConnectTimeout,
+		SourceReadTimeout:     bash.DefaultClientConfig().ReadTimeout,
+		SourceRetries:         bash.DefaultClientConfig().Retries,
+		SourceUserAgent:       bash.DefaultUserAgent,
+		SearchMaxResults:      bash.DefaultMaxResults,
+		SearchIndex:           true,
+		SearchIndexFile:       IndexPath,
+		SearchIndexMinResults: 10,
+		Dupes:                 true,
+		DupesThreshold:        dupes.DefaultThreshold,
+		DupesSize:             dupes.DefaultSize,
+
+		CacheSize: 1000,
+		CacheTTL:  time.Hour,
+
+		PrefetchSize:     100,
+		PrefetchLowWater: 20,
+
+		CrawlInterval: 15 * time.Minute,
+		CrawlDelay:    2 * time.Second,
+		CrawlPages:    5,
+		CrawlListings: []string{bash.ListingNew, bash.ListingAbyss},
+	}
+}
+
+// LoadConfig layers defaults, yaml file at path, BASHBOT_* environment
+// variables and flags, then validates the result
+func LoadConfig(path string, required bool, flags *helper.Flags) (Config, error) {
+	config := DefaultConfig()
+
+	err := helper.LoadConfig(path, required, EnvPrefix, &config)
+	if err != nil {
+		return Config{}, err
+	}
+	err = flags.Apply(&config)
+	if err != nil {
+		return Config{}, err
+	}
+	if config.Debug {
+		config.LogLevel = logging.DebugLevel.String()
+	}
+
+	if config.TokenFile != "" {
+		if config.Token != "" {
+			return Config{}, errors.New("invalid config: token and token_file are both set")
+		}
+		token, err := ioutil.ReadFile(config.TokenFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("can't read token_file: %s", err)
+		}
+		config.Token = strings.TrimSpace(string(token))
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid config: %s", err)
+	}
+	return config, nil
+}
+
+// Validate checks config values
+func (config *Config) Validate() error {
+	if config.Token == "" {
+		return errors.New("token is empty, set token or token_file")
+	}
+	if config.PoolSize < 1 {
+		return fmt.Errorf("pool_size must be positive, got %d", config.PoolSize)
+	}
+	if config.TimeOut < time.Millisecond {
+		return fmt.Errorf("timeout %s is too small, use duration like \"10s\"", config.TimeOut)
+	}
+	if config.FetchMaxAge <= 0 {
+		return fmt.Errorf("fetch_max_age must be positive, got %s", config.FetchMaxAge)
+	}
+	if config.SessionTTL < 0 {
+		return fmt.Errorf("session_ttl can't be negative, got %s", config.SessionTTL)
+	}
+	switch config.Source {
+	case BashSource:
+		if _, err := url.Parse(config.SourceURL); err != nil || config.SourceURL == "" {
+			return fmt.Errorf("source_url %q is not valid", config.SourceURL)
+		}
+		if config.SourceConnectTimeout <= 0 || config.SourceReadTimeout <= 0 {
+			return errors.New("source_connect_timeout and source_read_timeout must be positive")
+		}
+		if config.SourceRetries < 0 {
+			return fmt.Errorf("source_retries can't be negative, got %d", config.SourceRetries)
+		}
+	case StaticSource:
+		if config.SourceFile == "" {
+			return errors.New("source_file is required for static source")
+		}
+	case CorpusSource:
+	default:
+		return fmt.Errorf("unknown source %q", config.Source)
+	}
+	if config.SearchMaxResults < 1 {
+		return fmt.Errorf("search_max_results must be positive, got %d", config.SearchMaxResults)
+	}
+	if config.SearchIndexMinResults < 0 || config.SearchIndexMinResults > config.SearchMaxResults {
+		return fmt.Errorf("search_index_min_results must be between zero and search_max_results, got %d", config.SearchIndexMinResults)
+	}
+	if config.DupesThreshold <= 0 || config.DupesThreshold > 1 {
+		return fmt.Errorf("dupes_threshold must be in (0, 1], got %v", config.DupesThreshold)
+	}
+	if config.DupesSize < 0 {
+		return fmt.Errorf("dupes_size can't be negative, got %d", config.DupesSize)
+	}
+	if config.CacheSize < 0 || config.CacheTTL < 0 {
+		return errors.New("cache_size and cache_ttl can't be negative")
+	}
+	if config.PrefetchSize < 0 || config.PrefetchLowWater < 0 || config.PrefetchLowWater > config.PrefetchSize {
+		return errors.New("prefetch_low_water must be between zero and prefetch_size")
+	}
+	if config.CrawlInterval < 0 || config.CrawlDelay < 0 {
+		return errors.New("crawl_interval and crawl_delay can't be negative")
+	}
+	if config.CrawlPages < 1 {
+		return fmt.Errorf("crawl_pages must be positive, got %d", config.CrawlPages)
+	}
+	for _, listing := range config.CrawlListings {
+		if !knownListing(listing) {
+			return fmt.Errorf("unknown listing %q in crawl_listings", listing)
+		}
+	}
+	if config.ChatQueueDepth < 1 {
+		return fmt.Errorf("chat_queue_depth must be positive, got %d", config.ChatQueueDepth)
+	}
+	if config.RateLimit < 0 {
+		return fmt.Errorf("rate_limit can't be negative, got %v", config.RateLimit)
+	}
+	if config.RateLimit > 0 && config.RateBurst < 1 {
+		return fmt.Errorf("rate_burst must be positive, got %d", config.RateBurst)
+	}
+	if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+		return err
+	}
+	if config.LogFormat != logging.LogfmtFormat && config.LogFormat != logging.JSONFormat {
+		return fmt.Errorf("log_format must be %s or %s, got %q", logging.LogfmtFormat, logging.JSONFormat, config.LogFormat)
+	}
+	if strings.Count(config.Messages.TopHeader, "%") != 3 {
+		return errors.New("messages.top_header must have three verbs: window, page and pages")
+	}
+	if strings.Count(config.Messages.QuoteNotFound, "%") != 1 || strings.Count(config.Messages.QuotePending, "%") != 1 {
+		return errors.New("messages.quote_not_found and messages.quote_pending must have one verb: quote id")
+	}
+	if strings.Count(config.Messages.ComicCaption, "%") != 1 {
+		return errors.New("messages.comic_caption must have one verb: quote id")
+	}
+	for _, message := range []string{config.Messages.PossibleBayan, config.Messages.DupesHeader, config.Messages.NoDupes} {
+		if strings.Count(message, "%") != 1 {
+			return errors.New("messages.possible_bayan, messages.dupes_header and messages.no_dupes must have one verb: quote id")
+		}
+	}
+	if strings.Count(config.Messages.RatingHeader, "%") != 1 || strings.Count(config.Messages.NoRatings, "%") != 1 {
+		return errors.New("messages.rating_header and messages.no_ratings must have one verb: quote id")
+	}
+
+	if (config.Cert == "") != (config.PKey == "") {
+		return errors.New("cert and pkey must be set together")
+	}
+	if config.Cert == "" {
+		return nil
+	}
+
+	if config.Host == "" {
+		return errors.New("host is required for webhook")
+	}
+	if port, err := strconv.Atoi(config.Port); err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("port %q is not valid", config.Port)
+	}
+	for _, path := range []string{config.Cert, config.PKey} {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("can't use %q: %s", path, err)
+		}
+	}
+	return nil
 }
 
 // Menu constants
 const (
-	Start  = "/start"
 	Random = "Случайную"
 	Search = "Поиск"
 	Saved  = "Сохранненые"
@@ -32,17 +287,145 @@ const (
 	Other  = "Еще одну"
 	Back   = "Назад"
 	Delete = "Удалить"
+	Next   = "Дальше"
+	Prev   = "Раньше"
+	Share  = "Поделиться"
+
+	Sections = "Разделы"
+	NextPage = "Следующая страница"
+	PrevPage = "Предыдущая страница"
+
+	Comics       = "Комикс"
+	RandomComics = "Комиксы"
+	OtherComic   = "Еще комикс"
+	ToQuote      = "К цитате"
 )
 
-//Messages
+// Commands
 const (
-	WeHaveAnError = "У нас ошибочка:("
-	NothingToSend = "Пусто :("
-	BadThing      = "Что-то не то..."
-	SearchReq     = "Ищи!"
-	WhatSend      = "Что отправить?"
+	StartCommand  = "/start"
+	TopCommand    = "/top"
+	ReloadCommand = "/reload"
+	QuoteCommand  = "/quote"
+	DupesCommand  = "/dupes"
+	RatingCommand = "/rating"
 )
 
+// Messages catalog, can be overridden in config
+type Messages struct {
+	WeHaveAnError string `yaml:"we_have_an_error"`
+	NothingToSend string `yaml:"nothing_to_send"`
+	BadThing      string `yaml:"bad_thing"`
+	SearchReq     string `yaml:"search_req"`
+	WhatSend      string `yaml:"what_send"`
+	TopUsage      string `yaml:"top_usage"`
+	TopHeader     string `yaml:"top_header"`
+	ShareQuote    string `yaml:"share_quote"`
+	ShareSearch   string `yaml:"share_search"`
+	TooFast       string `yaml:"too_fast"`
+	Reloaded      string `yaml:"reloaded"`
+	Expired       string `yaml:"expired"`
+	ChooseSection string `yaml:"choose_section"`
+	QuoteUsage    string `yaml:"quote_usage"`
+	QuoteNotFound string `yaml:"quote_not_found"`
+	QuotePending  string `yaml:"quote_pending"`
+	VoteAccepted  string `yaml:"vote_accepted"`
+	VoteRepeated  string `yaml:"vote_repeated"`
+	VoteRejected  string `yaml:"vote_rejected"`
+	VoteFailed    string `yaml:"vote_failed"`
+	NoComic       string `yaml:"no_comic"`
+	ComicCaption  string `yaml:"comic_caption"`
+	PossibleBayan string `yaml:"possible_bayan"`
+	DupesUsage    string `yaml:"dupes_usage"`
+	DupesHeader   string `yaml:"dupes_header"`
+	NoDupes       string `yaml:"no_dupes"`
+	RatingUsage   string `yaml:"rating_usage"`
+	RatingHeader  string `yaml:"rating_header"`
+	NoRatings     string `yaml:"no_ratings"`
+}
+
+// DefaultMessages returns built-in messages
+func DefaultMessages() Messages {
+	return Messages{
+		WeHaveAnError: "У нас ошибочка:(",
+		NothingToSend: "Пусто :(",
+		BadThing:      "Что-то не то...",
+		SearchReq:     "Ищи!",
+		WhatSend:      "Что отправить?",
+		TopUsage:      "Используй /top day, /top week или /top all",
+		TopHeader:     "Топ %s (страница %d из %d)",
+		ShareQuote:    "Ссылка на цитату:",
+		ShareSearch:   "Ссылка на поиск:",
+		TooFast:       "Не так быстро!",
+		Reloaded:      "Конфигурация перечитана",
+		Expired:       "Давно не виделись, начнем сначала. Что отправить?",
+		ChooseSection: "Выбери раздел",
+		QuoteUsage:    "Используй /quote 12345",
+		QuoteNotFound: "Цитаты #%s нет",
+		QuotePending:  "Цитата #%s еще в Бездне, ее пока нельзя открыть",
+		VoteAccepted:  "Голос принят",
+		VoteRepeated:  "Ты уже голосовал за эту цитату",
+		VoteRejected:  "Голос не принят",
+		VoteFailed:    "bash.im недоступен, голос учтен только у нас",
+		NoComic:       "Комикса к этой цитате нет",
+		ComicCaption:  "Комикс к цитате #%s",
+		PossibleBayan: "Возможно баян #%s",
+		DupesUsage:    "Используй /dupes 12345",
+		DupesHeader:   "Похожие на #%s:",
+		NoDupes:       "Похожих на #%s не знаю",
+		RatingUsage:   "Используй /rating 12345",
+		RatingHeader:  "Рейтинг #%s на bash.im:",
+		NoRatings:     "Рейтинг #%s еще не записан",
+	}
+}
+
+// restartRequired lists settings which differ between configs and can't be changed at runtime
+func restartRequired(old, new Config) []string {
+	var changed []string
+	if old.Token != new.Token {
+		changed = append(changed, "token")
+	}
+	if old.Cert != new.Cert || old.PKey != new.PKey {
+		changed = append(changed, "cert")
+	}
+	if old.Host != new.Host || old.Port != new.Port {
+		changed = append(changed, "host")
+	}
+	if old.MetricsAddr != new.MetricsAddr {
+		changed = append(changed, "metrics_addr")
+	}
+	if old.Source != new.Source || old.SourceURL != new.SourceURL || old.SourceFile != new.SourceFile ||
+		old.SourceConnectTimeout != new.SourceConnectTimeout || old.SourceReadTimeout != new.SourceReadTimeout ||
+		old.SourceRetries != new.SourceRetries || old.SourceUserAgent != new.SourceUserAgent ||
+		old.SearchMaxResults != new.SearchMaxResults ||
+		old.SearchIndex != new.SearchIndex || old.SearchIndexFile != new.SearchIndexFile || old.SearchIndexMinResults != new.SearchIndexMinResults ||
+		old.Dupes != new.Dupes || old.DupesThreshold != new.DupesThreshold || old.DupesSize != new.DupesSize {
+		changed = append(changed, "source")
+	}
+	if old.CacheSize != new.CacheSize || old.CacheTTL != new.CacheTTL || old.CachePersist != new.CachePersist {
+		changed = append(changed, "cache")
+	}
+	if old.PrefetchSize != new.PrefetchSize || old.PrefetchLowWater != new.PrefetchLowWater {
+		changed = append(changed, "prefetch")
+	}
+	return changed
+}
+
+// crawlChanged reports whether crawler must be restarted to apply new config
+func crawlChanged(old, new Config) bool {
+	return old.CrawlInterval != new.CrawlInterval || old.CrawlDelay != new.CrawlDelay ||
+		old.CrawlPages != new.CrawlPages || strings.Join(old.CrawlListings, ",") != strings.Join(new.CrawlListings, ",")
+}
+
+func knownListing(listing string) bool {
+	for _, l := range bash.Listings {
+		if l == listing {
+			return true
+		}
+	}
+	return false
+}
+
 // WebhookConfig struct
 type WebhookConfig struct {
 	URL      *url.URL
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		yaml  string
+		env   map[string]string
+		args  []string
+		check func(config Config) bool
+		err   string
+	}{
+		{
+			name:  "defaults",
+			yaml:  "token : t",
+			check: func(c Config) bool { return c.PoolSize == 4 && c.TimeOut == 10*time.Second && c.LogLevel == "info" },
+		},
+		{
+			name:  "file overrides defaults",
+			yaml:  "token : t\npool_size : 8\ntimeout : 500ms",
+			check: func(c Config) bool { return c.PoolSize == 8 && c.TimeOut == 500*time.Millisecond },
+		},
+		{
+			name:  "environment overrides file",
+			yaml:  "token : t\npool_size : 8",
+			env:   map[string]string{"BASHBOT_POOL_SIZE": "6"},
+			check: func(c Config) bool { return c.PoolSize == 6 },
+		},
+		{
+			name:  "flags override environment",
+			yaml:  "token : t\npool_size : 8",
+			env:   map[string]string{"BASHBOT_POOL_SIZE": "6"},
+			args:  []string{"--pool-size", "2"},
+			check: func(c Config) bool { return c.PoolSize == 2 },
+		},
+		{
+			name:  "token from file",
+			yaml:  "token_file : " + tokenFile,
+			check: func(c Config) bool { return c.Token == "secret" },
+		},
+		{
+			name:  "old debug key",
+			yaml:  "token : t\ndebug : true",
+			check: func(c Config) bool { return c.LogLevel == "debug" },
+		},
+		{
+			name:  "empty keys of old sample",
+			yaml:  "token : t\ncert : \"\"\npkey : \"\"\nhost : \"\"\nport : \"\"\npool_size :\ntimeout : \ndebug : \n",
+			check: func(c Config) bool { return c.PoolSize == 4 && c.TimeOut == 10*time.Second && c.Port == "" },
+		},
+		{name: "unknown key", yaml: "token : t\npool_sise : 8", err: "pool_sise"},
+		{name: "no token", yaml: "pool_size : 8", err: "token is empty"},
+		{name: "token twice", yaml: "token : t\ntoken_file : " + tokenFile, err: "both set"},
+		{name: "zero pool", yaml: "token : t\npool_size : 0", err: "pool_size must be positive"},
+		{name: "timeout in milliseconds", yaml: "token : t\ntimeout : 5000", err: "too small"},
+		{name: "bad environment", yaml: "token : t", env: map[string]string{"BASHBOT_TIMEOUT": "soon"}, err: "BASHBOT_TIMEOUT"},
+		{name: "cert without pkey", yaml: "token : t\ncert : c.pem", err: "cert and pkey"},
+		{name: "unknown source", yaml: "token : t\nsource : ftp", err: "unknown source"},
+		{name: "static without file", yaml: "token : t\nsource : static", err: "source_file"},
+		{name: "bad low water", yaml: "token : t\nprefetch_size : 5\nprefetch_low_water : 6", err: "prefetch_low_water"},
+		{name: "bad top header", yaml: "token : t\nmessages :\n  top_header : \"Топ %s\"", err: "top_header"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, "config.yml")
+			if err := ioutil.WriteFile(path, []byte(test.yaml), 0600); err != nil {
+				t.Fatal(err)
+			}
+			for key, value := range test.env {
+				os.Setenv(key, value)
+				defer os.Unsetenv(key)
+			}
+			fs := flag.NewFlagSet("bot", flag.ContinueOnError)
+			flags := helper.NewFlags(fs, "", &Config{})
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path, true, flags)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !test.check(config) {
+				t.Errorf("unexpected config %+v", config)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	os.Setenv("BASHBOT_TOKEN", "t")
+	defer os.Unsetenv("BASHBOT_TOKEN")
+
+	if _, err := LoadConfig("missing.yml", false, nil); err != nil {
+		t.Errorf("default file is required: %s", err)
+	}
+	if _, err := LoadConfig("missing.yml", true, nil); err == nil {
+		t.Error("missing given file is not reported")
+	}
+}
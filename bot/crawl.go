@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/crawler"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// maxRatings limits number of snapshots listed by /rating
+const maxRatings = 20
+
+// ratingHistory is a store which keeps ratings of quotes on bash.im
+type ratingHistory interface {
+	RatingHistory(quoteID string) ([]database.RatingSnapshot, error)
+}
+
+// startCrawler starts crawling listings of source into corpus if config
+// asks for it and source can be browsed
+func (bot *Bot) startCrawler(config Config) {
+	browser, ok := bot.Source.(bash.Browser)
+	if !ok || !bot.Source.Capabilities().Has(bash.CanBrowse) || config.CrawlInterval <= 0 {
+		return
+	}
+	db, ok := bot.DB.(*database.Tarantool)
+	if !ok {
+		return
+	}
+
+	c := crawler.New(browser, database.NewCorpus(db), db, crawler.Config{
+		Interval: config.CrawlInterval,
+		Delay:    config.CrawlDelay,
+		Pages:    config.CrawlPages,
+		Listings: config.CrawlListings,
+	})
+	c.Client = sourceClient(bot.Source)
+	c.Start()
+
+	bot.mu.Lock()
+	bot.crawler = c
+	bot.mu.Unlock()
+}
+
+// stopCrawler stops crawler if it runs
+func (bot *Bot) stopCrawler() {
+	bot.mu.Lock()
+	c := bot.crawler
+	bot.crawler = nil
+	bot.mu.Unlock()
+
+	if c != nil {
+		c.Stop()
+	}
+}
+
+// currentCrawler returns running crawler or nil
+func (bot *Bot) currentCrawler() *crawler.Crawler {
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+	return bot.crawler
+}
+
+// Stop finishes background work of bot
+func (bot *Bot) Stop() {
+	bot.stopCrawler()
+	if bot.prefetch != nil {
+		bot.prefetch.Stop()
+	}
+}
+
+func (bot *Bot) ratingCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	quoteID, ok := bot.parseQuoteRef(args)
+	history, found := bot.DB.(ratingHistory)
+	if !ok || !found {
+		_, err := bot.API.SendText(id, bot.messages().RatingUsage)
+		if err != nil {
+			return fmt.Errorf("can't send message: %s", err)
+		}
+		return nil
+	}
+
+	snapshots, err := history.RatingHistory(quoteID)
+	if err != nil {
+		return fmt.Errorf("can't get rating history of %s: %s", quoteID, err)
+	}
+
+	_, err = bot.API.SendText(id, bot.ratingsToString(quoteID, snapshots))
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
+
+// ratingsToString lists the latest snapshots with change since the previous one
+func (bot *Bot) ratingsToString(quoteID string, snapshots []database.RatingSnapshot) string {
+	if len(snapshots) == 0 {
+		return fmt.Sprintf(bot.messages().NoRatings, quoteID)
+	}
+
+	first := 0
+	if len(snapshots) > maxRatings {
+		first = len(snapshots) - maxRatings
+	}
+
+	str := fmt.Sprintf(bot.messages().RatingHeader, quoteID) + "\n\n"
+	for i := first; i < len(snapshots); i++ {
+		snapshot := snapshots[i]
+		str += fmt.Sprintf("%s — %d", snapshot.At.Format("02.01.2006 15:04"), snapshot.Rating)
+		if i > 0 {
+			str += fmt.Sprintf(" (%+d)", snapshot.Rating-snapshots[i-1].Rating)
+		}
+		str += "\n"
+	}
+	return str + bot.quoteLink(quoteID)
+}
@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/dupes"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// maxDupes limits number of quotes listed by /dupes
+const maxDupes = 10
+
+// detector returns duplicate detector of source if there is one
+func (bot *Bot) detector() (*dupes.Detector, bool) {
+	for _, s := range sourceChain(bot.Source) {
+		if source, ok := s.(*dupes.Source); ok {
+			return source.Detector, true
+		}
+	}
+	return nil, false
+}
+
+// quoteText returns text of quote message, repeated quote gets link to the original
+func (bot *Bot) quoteText(quote bash.Quote) string {
+	text := bash.QuoteToString(quote)
+
+	detector, ok := bot.detector()
+	if !ok {
+		return text
+	}
+	original, ok := detector.Original(quote.ID)
+	if !ok {
+		return text
+	}
+	return text + "\n" + fmt.Sprintf(bot.messages().PossibleBayan, original.ID) + "\n" + bot.quoteLink(original.ID)
+}
+
+func (bot *Bot) dupesCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	quoteID, ok := bot.parseQuoteRef(args)
+	detector, found := bot.detector()
+	if !ok || !found {
+		_, err := bot.API.SendText(id, bot.messages().DupesUsage)
+		if err != nil {
+			return fmt.Errorf("can't send message: %s", err)
+		}
+		return nil
+	}
+
+	matches, known := detector.Similar(quoteID)
+	if !known {
+		// quote is remembered by detector once source returns it
+		_, err := bot.Source.ByID(quoteID)
+		switch err {
+		case nil:
+			matches, _ = detector.Similar(quoteID)
+		case bash.ErrNotFound, bash.ErrPending:
+			_, err = bot.API.SendText(id, fmt.Sprintf(bot.messages().QuoteNotFound, quoteID))
+			if err != nil {
+				return fmt.Errorf("can't send message: %s", err)
+			}
+			return nil
+		default:
+			return fmt.Errorf("can't get quote %s: %s", quoteID, err)
+		}
+	}
+
+	_, err := bot.API.SendText(id, bot.dupesToString(quoteID, matches))
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
+
+func (bot *Bot) dupesToString(quoteID string, matches []dupes.Match) string {
+	if len(matches) == 0 {
+		return fmt.Sprintf(bot.messages().NoDupes, quoteID)
+	}
+	if len(matches) > maxDupes {
+		matches = matches[:maxDupes]
+	}
+
+	str := fmt.Sprintf(bot.messages().DupesHeader, quoteID) + "\n\n"
+	for _, match := range matches {
+		str += fmt.Sprintf("#%s — %.0f%%\n%s\n", match.Quote.ID, match.Similarity*100, bot.quoteLink(match.Quote.ID))
+	}
+	return str
+}
@@ -0,0 +1,29 @@
+package bot
+
+import (
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// foundQuotes is result of the search of chat, it is kept while chat goes
+// through it, so source is asked once per search
+type foundQuotes struct {
+	query  string
+	quotes []bash.Quote
+}
+
+// searchQuotes returns kept result of query in chat, source is searched
+// when there is none (e.g. after restart)
+func (bot *Bot) searchQuotes(id int, query string) ([]bash.Quote, error) {
+	if value, ok := bot.found.get(id); ok {
+		if found := value.(foundQuotes); found.query == query {
+			return found.quotes, nil
+		}
+	}
+
+	quotes, err := bot.Source.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	bot.found.set(id, foundQuotes{query: query, quotes: quotes})
+	return quotes, nil
+}
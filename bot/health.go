@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/index"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+)
+
+// checkTimeout limits every readiness check
+const checkTimeout = 5 * time.Second
+
+// serveHealth serves /healthz, /readyz and /metrics on addr
+func (bot *Bot) serveHealth(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", bot.readyHandler)
+	mux.Handle("/metrics", metrics.Handler())
+
+	logging.Infof("health endpoints on %s", addr)
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		logging.Errorf("health endpoints stopped: %s", err)
+	}
+}
+
+func (bot *Bot) readyHandler(w http.ResponseWriter, r *http.Request) {
+	checks := []struct {
+		name  string
+		check func() error
+	}{
+		{"tarantool", bot.DB.Ping},
+		{"telegram", func() error {
+			_, err := bot.API.GetMe()
+			return err
+		}},
+		{"bash", bot.checkFetch},
+	}
+
+	ready := true
+	report := ""
+	for _, c := range checks {
+		err := withTimeout(c.check, checkTimeout)
+		if err != nil {
+			ready = false
+			report += c.name + ": " + err.Error() + "\n"
+		} else {
+			report += c.name + ": ok\n"
+		}
+	}
+
+	if client := sourceClient(bot.Source); client != nil {
+		report += "bash.im circuit: " + client.BreakerState().String() + "\n"
+	}
+	for _, source := range sourceChain(bot.Source) {
+		switch source := source.(type) {
+		case *bash.CachedSource:
+			stats := source.Cache.Stats()
+			report += fmt.Sprintf("quote cache: %d quotes, %d hits, %d misses\n", stats.Len, stats.Hits, stats.Misses)
+		case *index.Source:
+			report += fmt.Sprintf("search index: %d quotes\n", source.Index.Len())
+		}
+	}
+	if success, _ := bash.LastFetch(); !success.IsZero() {
+		report += fmt.Sprintf("last bash.im fetch: %s ago\n", time.Since(success).Round(time.Second))
+	}
+	if crawler := bot.currentCrawler(); crawler != nil {
+		if last := crawler.LastCrawl(); !last.IsZero() {
+			report += fmt.Sprintf("last crawl: %s ago\n", time.Since(last).Round(time.Second))
+		}
+	}
+
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	fmt.Fprint(w, report)
+}
+
+// checkFetch fails if circuit to bash.im is open or the last fetch failed
+// and there was no successful one for too long
+func (bot *Bot) checkFetch() error {
+	if client := sourceClient(bot.Source); client != nil && client.BreakerState() == bash.BreakerOpen {
+		return bash.ErrCircuitOpen
+	}
+
+	success, failure := bash.LastFetch()
+	if failure.Before(success) || failure.IsZero() {
+		return nil
+	}
+	if success.IsZero() {
+		return errors.New("bash.im is unavailable")
+	}
+	if time.Since(success) > bot.settings().FetchMaxAge {
+		return errors.New("bash.im is unavailable since " + success.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func withTimeout(f func() error, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.New("timed out")
+	}
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/pool"
+)
+
+var (
+	updatesTotal = metrics.NewCounter("bot_updates_total",
+		"Handled updates by processor or command", "processor")
+	updateErrors = metrics.NewCounter("bot_update_errors_total",
+		"Updates which handler returned error, by processor or command", "processor")
+	handlerDuration = metrics.NewHistogram("bot_handler_duration_seconds",
+		"Time spent in update handler by processor or command", metrics.DefaultBuckets, "processor")
+
+	poolMetricsOnce     sync.Once
+	prefetchMetricsOnce sync.Once
+)
+
+func observeUpdate(name string, duration time.Duration, err error) {
+	updatesTotal.Inc(name)
+	handlerDuration.Observe(duration.Seconds(), name)
+	if err != nil {
+		updateErrors.Inc(name)
+	}
+}
+
+func registerPoolMetrics(p *pool.Pool) {
+	poolMetricsOnce.Do(func() {
+		metrics.NewGaugeFunc("pool_queue_depth", "Tasks waiting for a free worker", func() float64 {
+			return float64(p.QueueDepth())
+		})
+		metrics.NewGaugeFunc("pool_workers", "Workers of pool", func() float64 {
+			return float64(p.Size())
+		})
+		metrics.NewCounterFunc("pool_timeouts_total", "Tasks which timed out waiting for a worker", func() float64 {
+			return float64(p.Timeouts())
+		})
+	})
+}
+
+func registerPrefetchMetrics(p *bash.Prefetcher) {
+	prefetchMetricsOnce.Do(func() {
+		metrics.NewGaugeFunc("prefetch_buffered_quotes", "Random quotes waiting in prefetch buffer", func() float64 {
+			return float64(p.Len())
+		})
+	})
+}
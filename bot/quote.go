@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+var quoteNumber = regexp.MustCompile(`^#?([0-9]{1,9})$`)
+
+// parseQuoteRef returns id of quote referenced as "#12345", "12345" or
+// address of quote on bash.im or source site
+func (bot *Bot) parseQuoteRef(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if m := quoteNumber.FindStringSubmatch(text); m != nil {
+		return m[1], true
+	}
+
+	address, err := url.Parse(text)
+	if err != nil || address.Host == "" {
+		return "", false
+	}
+	host := strings.TrimPrefix(address.Host, "www.")
+	if host != "bash.im" && host != bot.sourceHost() {
+		return "", false
+	}
+
+	path := strings.TrimSuffix(address.Path, "/")
+	if !strings.HasPrefix(path, "/quote/") {
+		return "", false
+	}
+	if m := quoteNumber.FindStringSubmatch(strings.TrimPrefix(path, "/quote/")); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// isBareNumber reports whether text is number of quote without "#"
+func isBareNumber(text string) bool {
+	text = strings.TrimSpace(text)
+	return !strings.HasPrefix(text, "#") && quoteNumber.MatchString(text)
+}
+
+func (bot *Bot) sourceHost() string {
+	address, err := url.Parse(bot.settings().SourceURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(address.Host, "www.")
+}
+
+func (bot *Bot) quoteCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	quoteID, ok := bot.parseQuoteRef(args)
+	if !ok {
+		_, err := bot.API.SendText(id, bot.messages().QuoteUsage)
+		if err != nil {
+			return fmt.Errorf("can't send message: %s", err)
+		}
+		return nil
+	}
+	return bot.openQuote(id, quoteID)
+}
+
+// openQuote sends quote with vote buttons, state of chat is kept if there is no such quote
+func (bot *Bot) openQuote(id int, quoteID string) error {
+	quote, err := bot.Source.ByID(quoteID)
+
+	var text string
+	switch err {
+	case nil:
+		return bot.sendQuote(id, quote)
+	case bash.ErrNotFound:
+		text = fmt.Sprintf(bot.messages().QuoteNotFound, quoteID)
+	case bash.ErrPending:
+		text = fmt.Sprintf(bot.messages().QuotePending, quoteID)
+	default:
+		_, sendErr := bot.API.SendText(id, bot.messages().WeHaveAnError)
+		if sendErr != nil {
+			return fmt.Errorf("can't get quote %s: %s, can't send message: %s", quoteID, err, sendErr)
+		}
+		return fmt.Errorf("can't get quote %s: %s", quoteID, err)
+	}
+
+	_, err = bot.API.SendText(id, text)
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
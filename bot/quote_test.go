@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// textMessenger keeps texts sent by bot
+type textMessenger struct {
+	Messenger
+	texts []string
+}
+
+func (m *textMessenger) SendText(chatID int, text string) (telegram.Message, error) {
+	m.texts = append(m.texts, text)
+	return telegram.Message{}, nil
+}
+
+// errorSource fails ByID with err
+type errorSource struct {
+	bash.QuoteSource
+	err error
+}
+
+func (s errorSource) ByID(id string) (bash.Quote, error) {
+	return bash.Quote{}, s.err
+}
+
+func TestParseQuoteRef(t *testing.T) {
+	config := DefaultConfig()
+	config.SourceURL = "https://mirror.example.org/"
+	bot := &Bot{config: config}
+
+	tests := []struct {
+		text string
+		id   string
+		ok   bool
+	}{
+		{"#12345", "12345", true},
+		{"12345", "12345", true},
+		{"  #7 ", "7", true},
+		{"https://bash.im/quote/12345", "12345", true},
+		{"http://www.bash.im/quote/12345/", "12345", true},
+		{"https://mirror.example.org/quote/42", "42", true},
+		{"https://example.com/quote/42", "", false},
+		{"https://bash.im/abyss", "", false},
+		{"https://bash.im/quote/abc", "", false},
+		{"#1234567890", "", false},
+		{"quote", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		id, ok := bot.parseQuoteRef(test.text)
+		if id != test.id || ok != test.ok {
+			t.Errorf("parseQuoteRef(%q) = %q, %v, want %q, %v", test.text, id, ok, test.id, test.ok)
+		}
+	}
+}
+
+func TestOpenQuoteReplies(t *testing.T) {
+	messages := DefaultMessages()
+	tests := []struct {
+		err     error
+		text    string
+		failure bool
+	}{
+		{bash.ErrNotFound, fmt.Sprintf(messages.QuoteNotFound, "1"), false},
+		{bash.ErrPending, fmt.Sprintf(messages.QuotePending, "1"), false},
+		{errors.New("timeout"), messages.WeHaveAnError, true},
+	}
+	for _, test := range tests {
+		api := &textMessenger{}
+		bot := &Bot{API: api, Source: errorSource{err: test.err}, config: DefaultConfig()}
+
+		err := bot.openQuote(1, "1")
+		if (err != nil) != test.failure {
+			t.Errorf("openQuote with %v: error = %v", test.err, err)
+		}
+		if len(api.texts) != 1 || api.texts[0] != test.text {
+			t.Errorf("openQuote with %v sent %q, want %q", test.err, api.texts, test.text)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxBuckets is a number of chats after which full buckets are dropped
+const maxBuckets = 10000
+
+// rateLimiter is a token bucket per chat
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[int]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	warned bool
+}
+
+// newRateLimiter creates limiter, zero rate means no limit
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[int]*bucket),
+	}
+}
+
+func (l *rateLimiter) setLimits(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = burst
+	l.buckets = make(map[int]*bucket)
+}
+
+// allow takes token from bucket of chat
+func (l *rateLimiter) allow(chatID int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	b, ok := l.buckets[chatID]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.cleanup(now)
+		}
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[chatID] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	b.warned = false
+	return true
+}
+
+// warn reports whether chat should be warned about limit, only once until next allowed update
+func (l *rateLimiter) warn(chatID int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[chatID]
+	if !ok || b.warned {
+		return false
+	}
+	b.warned = true
+	return true
+}
+
+func (l *rateLimiter) cleanup(now time.Time) {
+	for chatID, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, chatID)
+		}
+	}
+}
+
+func (bot *Bot) sendTooFast(id int) error {
+	if !bot.limiter.warn(id) {
+		return nil
+	}
+	_, err := bot.API.SendText(id, bot.messages().TooFast)
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
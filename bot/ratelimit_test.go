@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		want  int
+	}{
+		{"no limit", 0, 0, 10},
+		{"burst", 1, 3, 3},
+		{"single", 0.5, 1, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := newRateLimiter(test.rate, test.burst)
+			allowed := 0
+			for i := 0; i < 10; i++ {
+				if limiter.allow(1) {
+					allowed++
+				}
+			}
+			if allowed != test.want {
+				t.Errorf("allowed %d updates, want %d", allowed, test.want)
+			}
+			if !limiter.allow(2) {
+				t.Errorf("other chat is limited")
+			}
+		})
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	limiter := newRateLimiter(100, 1)
+	if !limiter.allow(1) || limiter.allow(1) {
+		t.Fatal("bucket of one token is not used up")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !limiter.allow(1) {
+		t.Error("bucket is not refilled")
+	}
+}
+
+func TestRateLimiterWarnsOnce(t *testing.T) {
+	limiter := newRateLimiter(0.001, 1)
+	limiter.allow(1)
+	limiter.allow(1)
+	if !limiter.warn(1) {
+		t.Error("limited chat is not warned")
+	}
+	if limiter.warn(1) {
+		t.Error("chat is warned twice")
+	}
+
+	limiter.setLimits(0.001, 2)
+	if !limiter.allow(1) {
+		t.Error("new limits do not reset buckets")
+	}
+}
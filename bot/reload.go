@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// Loader reads bot and database configs
+type Loader func() (Config, database.Config, error)
+
+// settings returns current config
+func (bot *Bot) settings() Config {
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+	return bot.config
+}
+
+// messages returns current messages catalog
+func (bot *Bot) messages() Messages {
+	bot.mu.RLock()
+	defer bot.mu.RUnlock()
+	return bot.config.Messages
+}
+
+func (bot *Bot) isAdmin(userID int) bool {
+	for _, admin := range bot.settings().Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// watchReload reloads config on SIGHUP
+func (bot *Bot) watchReload() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		report, err := bot.reload()
+		if err != nil {
+			logging.Errorf("can't reload config: %s", err)
+			continue
+		}
+		logging.Infof("%s", report)
+	}
+}
+
+func (bot *Bot) reloadCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	if userID, ok := senderID(update); !ok || !bot.isAdmin(userID) {
+		return bot.start(id, bot.messages().BadThing)
+	}
+
+	report, err := bot.reload()
+	if err != nil {
+		report = fmt.Sprintf("can't reload config: %s", err)
+	}
+
+	_, err = bot.API.SendText(id, report)
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
+
+// reload reads config again and applies settings which can be changed at runtime,
+// the report lists settings which need restart
+func (bot *Bot) reload() (string, error) {
+	if bot.load == nil {
+		return "", errors.New("config has no source to reload from")
+	}
+
+	bot.reloadMu.Lock()
+	defer bot.reloadMu.Unlock()
+
+	config, dbConfig, err := bot.load()
+	if err != nil {
+		return "", err
+	}
+
+	bot.mu.Lock()
+	old, oldDB := bot.config, bot.dbConfig
+	restart := restartRequired(old, config)
+	if !reflect.DeepEqual(oldDB, dbConfig) {
+		restart = append(restart, "db")
+	}
+
+	// settings which need restart are kept until it
+	config.Token, config.TokenFile = old.Token, old.TokenFile
+	config.Cert, config.PKey = old.Cert, old.PKey
+	config.Host, config.Port = old.Host, old.Port
+	config.MetricsAddr = old.MetricsAddr
+	config.Source, config.SourceURL, config.SourceFile = old.Source, old.SourceURL, old.SourceFile
+	bot.config = config
+	bot.mu.Unlock()
+
+	bot.Pool.Resize(config.PoolSize)
+	bot.limiter.setLimits(config.RateLimit, config.RateBurst)
+	bot.queues.setDepth(config.ChatQueueDepth)
+	bot.applyLogLevel(config)
+	if crawlChanged(old, config) {
+		bot.stopCrawler()
+		bot.startCrawler(config)
+	}
+
+	report := config.Messages.Reloaded
+	if len(restart) != 0 {
+		report += "\nrestart is required to apply: " + strings.Join(restart, ", ")
+		logging.Warnf("restart is required to apply: %s", strings.Join(restart, ", "))
+	}
+	return report, nil
+}
+
+func (bot *Bot) applyLogLevel(config Config) {
+	level, err := logging.ParseLevel(config.LogLevel)
+	if err != nil {
+		logging.Errorf("%s", err)
+		return
+	}
+	logging.SetLevel(level)
+
+	err = logging.SetFormat(config.LogFormat)
+	if err != nil {
+		logging.Errorf("%s", err)
+	}
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+)
+
+// sweepInterval is the longest pause between sweeps of idle sessions
+const sweepInterval = time.Minute
+
+// sweepSessions removes sessions idle for longer than session_ttl and
+// returns their chats to the main menu
+func (bot *Bot) sweepSessions() {
+	for {
+		ttl := bot.settings().SessionTTL
+
+		interval := sweepInterval
+		if ttl != 0 && ttl/10 < interval {
+			interval = ttl / 10
+		}
+		time.Sleep(interval)
+
+		if ttl == 0 {
+			continue
+		}
+
+		since := time.Now().Add(-ttl)
+		chats, err := bot.DB.IdleSessions(since)
+		if err != nil {
+			logging.Errorf("can't get idle sessions: %s", err)
+			continue
+		}
+
+		// expiry waits for updates of chat, busy chat is not idle anyway
+		for _, id := range chats {
+			id := id
+			bot.queues.push(id, func() { bot.expireSession(id, since) })
+		}
+	}
+}
+
+// expireSession removes session of chat if it is still idle since given time
+func (bot *Bot) expireSession(id int, since time.Time) {
+	logger := logging.With("chat_id", id)
+
+	last, err := bot.DB.LastActive(id)
+	if err != nil {
+		logger.Errorf("can't get session: %s", err)
+		return
+	}
+	if !last.Before(since) {
+		return
+	}
+
+	err = bot.DB.RemoveSession(id)
+	if err != nil {
+		logger.Errorf("can't remove session: %s", err)
+		return
+	}
+	bot.shown.remove(id)
+	bot.found.remove(id)
+
+	err = bot.start(id, bot.messages().Expired)
+	if err != nil {
+		logger.Warnf("can't return to menu: %s", err)
+	}
+	logger.Debugf("session expired")
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// Deep link payload prefixes
+const (
+	QuotePayload  = "q_"
+	SearchPayload = "s_"
+)
+
+// deepLinkEndpoint mask, takes bot username and payload
+const deepLinkEndpoint = "https://t.me/%s?start=%s"
+
+// maxPayloadLength is a limit of telegram for start parameter
+const maxPayloadLength = 64
+
+func (bot *Bot) startCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	switch {
+	case strings.HasPrefix(args, QuotePayload):
+		return bot.openQuote(id, strings.TrimPrefix(args, QuotePayload))
+	case strings.HasPrefix(args, SearchPayload):
+		query, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(args, SearchPayload))
+		if err != nil || len(query) == 0 {
+			return bot.start(id, bot.messages().BadThing)
+		}
+		return bot.beginSearch(id, string(query))
+	default:
+		return bot.start(id, bot.messages().WhatSend)
+	}
+}
+
+// sendShare sends deep link to quote and, if query is not empty, to search
+func (bot *Bot) sendShare(id int, quoteID string, query string) error {
+	text := bot.messages().ShareQuote + "\n" + bot.quoteLink(quoteID)
+	if link, ok := bot.searchLink(query); ok && query != "" {
+		text += "\n\n" + bot.messages().ShareSearch + "\n" + link
+	}
+
+	_, err := bot.API.SendText(id, text)
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+	return nil
+}
+
+// quoteLink builds deep link which opens quote in bot
+func (bot *Bot) quoteLink(quoteID string) string {
+	return fmt.Sprintf(deepLinkEndpoint, bot.Self.UserName, QuotePayload+quoteID)
+}
+
+// searchLink builds deep link which starts search in bot, query may be too long for it
+func (bot *Bot) searchLink(query string) (string, bool) {
+	payload := SearchPayload + base64.RawURLEncoding.EncodeToString([]byte(query))
+	if len(payload) > maxPayloadLength {
+		return "", false
+	}
+	return fmt.Sprintf(deepLinkEndpoint, bot.Self.UserName, payload), true
+}
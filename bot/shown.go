@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// shownQuote is the last message with quote sent to chat
+type shownQuote struct {
+	messageID int
+	quote     bash.Quote
+
+	// listing and page are set when quote is browsed
+	listing string
+	page    *bash.Page
+}
+
+// shownQuotes remembers last quote message of recently active chats, so it
+// can be edited when result of vote is known
+type shownQuotes struct {
+	chats *chatCache
+}
+
+func newShownQuotes() *shownQuotes {
+	return &shownQuotes{chats: newChatCache(maxKeptChats)}
+}
+
+func (s *shownQuotes) get(chatID int) (shownQuote, bool) {
+	value, ok := s.chats.get(chatID)
+	if !ok {
+		return shownQuote{}, false
+	}
+	return value.(shownQuote), true
+}
+
+func (s *shownQuotes) set(chatID int, shown shownQuote) {
+	s.chats.set(chatID, shown)
+}
+
+// setPage remembers page of listing the shown quote of chat belongs to
+func (s *shownQuotes) setPage(chatID int, listing string, page bash.Page) {
+	s.chats.update(chatID, func(value interface{}) interface{} {
+		shown := value.(shownQuote)
+		shown.listing, shown.page = listing, &page
+		return shown
+	})
+}
+
+func (s *shownQuotes) remove(chatID int) {
+	s.chats.remove(chatID)
+}
+
+// sendQuoteMessage sends quote with buttons and remembers the message
+func (bot *Bot) sendQuoteMessage(id int, quote bash.Quote, buttons telegram.ReplyKeyboardMarkup) error {
+	message, err := bot.API.SendTextWithKeybord(id, bot.quoteText(quote), buttons)
+	if err != nil {
+		return err
+	}
+	bot.shown.set(id, shownQuote{messageID: message.MessageID, quote: quote})
+	return nil
+}
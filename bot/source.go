@@ -0,0 +1,173 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/dupes"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/index"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+)
+
+// Quote sources
+const (
+	BashSource   = "bash"
+	StaticSource = "static"
+	CorpusSource = "corpus"
+)
+
+// NewSource creates quote source selected by config and wraps it with cache,
+// search index and duplicate detector, db is needed only for corpus and
+// persistent cache
+func NewSource(config Config, db *database.Tarantool) (bash.QuoteSource, error) {
+	source, err := newSource(config, db)
+	if err != nil {
+		return nil, err
+	}
+	base := source
+
+	if config.CacheSize > 0 {
+		cache := bash.NewCache(config.CacheSize, config.CacheTTL)
+		if config.CachePersist && db != nil {
+			cache.Store = db
+		}
+		source = bash.NewCachedSource(source, cache)
+	}
+
+	var idx *index.Index
+	if config.SearchIndex {
+		idx, err = openIndex(config.SearchIndexFile)
+		if err != nil {
+			return nil, err
+		}
+		// imported quotes are indexed once, unchanged ones are skipped
+		err = eachImported(base, func(quote bash.Quote) error {
+			return idx.Add(quote)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't index quotes: %s", err)
+		}
+		if corpus, ok := base.(*database.Corpus); ok {
+			corpus.SetIndex(idx)
+		}
+		source = index.NewSource(source, idx, config.SearchIndexMinResults, config.SearchMaxResults)
+	}
+
+	if config.Dupes {
+		detector := dupes.New(config.DupesThreshold, config.DupesSize)
+		// crawled quotes go first, so quotes seen later are kept when
+		// there are more quotes than detector keeps
+		if _, ok := base.(*database.Corpus); !ok && db != nil {
+			err := database.NewCorpus(db).Each(func(quote bash.Quote) bool {
+				detector.Add(quote)
+				return true
+			})
+			if err != nil {
+				logging.Warnf("can't add crawled quotes to duplicate detector: %s", err)
+			}
+		}
+		// index already has imported quotes and every quote seen before
+		if idx != nil {
+			idx.Each(func(quote bash.Quote) bool {
+				detector.Add(quote)
+				return true
+			})
+		} else {
+			eachImported(base, func(quote bash.Quote) error {
+				detector.Add(quote)
+				return nil
+			})
+		}
+		source = dupes.NewSource(source, detector)
+	}
+	return source, nil
+}
+
+// eachImported calls f for every quote of corpus or static source until f fails
+func eachImported(source bash.QuoteSource, f func(quote bash.Quote) error) error {
+	switch source := source.(type) {
+	case *database.Corpus:
+		var fErr error
+		err := source.Each(func(quote bash.Quote) bool {
+			fErr = f(quote)
+			return fErr == nil
+		})
+		if err != nil {
+			return err
+		}
+		return fErr
+	case *bash.Static:
+		for _, quote := range source.Quotes() {
+			if err := f(quote); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func openIndex(path string) (*index.Index, error) {
+	if path == "" {
+		return index.New(), nil
+	}
+	return index.Open(path)
+}
+
+func newSource(config Config, db *database.Tarantool) (bash.QuoteSource, error) {
+	switch config.Source {
+	case BashSource:
+		scraper := bash.NewScraper(config.SourceURL)
+		scraper.Client = bash.NewClient(clientConfig(config))
+		scraper.MaxResults = config.SearchMaxResults
+		return scraper, nil
+	case StaticSource:
+		return bash.LoadStatic(config.SourceFile)
+	case CorpusSource:
+		if db == nil {
+			return nil, errors.New("corpus source needs tarantool")
+		}
+		corpus := database.NewCorpus(db)
+		corpus.MaxResults = config.SearchMaxResults
+		return corpus, nil
+	default:
+		return nil, fmt.Errorf("unknown source %q", config.Source)
+	}
+}
+
+func clientConfig(config Config) bash.ClientConfig {
+	client := bash.DefaultClientConfig()
+	client.ConnectTimeout = config.SourceConnectTimeout
+	client.ReadTimeout = config.SourceReadTimeout
+	client.Retries = config.SourceRetries
+	if config.SourceUserAgent != "" {
+		client.UserAgent = config.SourceUserAgent
+	}
+	return client
+}
+
+// sourceChain returns source and sources wrapped by it
+func sourceChain(source bash.QuoteSource) []bash.QuoteSource {
+	chain := []bash.QuoteSource{source}
+	for {
+		wrapper, ok := source.(interface {
+			Unwrap() bash.QuoteSource
+		})
+		if !ok {
+			return chain
+		}
+		source = wrapper.Unwrap()
+		chain = append(chain, source)
+	}
+}
+
+// sourceClient returns client of bash.im used by source if there is one
+func sourceClient(source bash.QuoteSource) *bash.Client {
+	for _, s := range sourceChain(source) {
+		if scraper, ok := s.(*bash.Scraper); ok {
+			return scraper.Client
+		}
+	}
+	return nil
+}
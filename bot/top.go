@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// Top windows
+const (
+	TopDay  = "day"
+	TopWeek = "week"
+	TopAll  = "all"
+)
+
+const topPageSize = 10
+
+var topWindows = map[string]struct {
+	period time.Duration
+	title  string
+}{
+	TopDay:  {24 * time.Hour, "за день"},
+	TopWeek: {7 * 24 * time.Hour, "за неделю"},
+	TopAll:  {0, "за все время"},
+}
+
+func (bot *Bot) topCommand(update *telegram.Update, args string) error {
+	id := update.Message.Chat.ID
+
+	if args == "" {
+		args = TopDay
+	}
+	if _, ok := topWindows[args]; !ok {
+		return bot.start(id, bot.messages().TopUsage)
+	}
+
+	return bot.sendTop(id, args, 0)
+}
+
+func (bot *Bot) sendTop(id int, window string, page int) error {
+	var since time.Time
+	if period := topWindows[window].period; period != 0 {
+		since = time.Now().Add(-period)
+	}
+
+	scores, err := bot.DB.GetTop(since)
+	if err != nil {
+		return fmt.Errorf("can't get top: %s", err)
+	}
+	if len(scores) == 0 {
+		return bot.start(id, bot.messages().NothingToSend)
+	}
+
+	pages := (len(scores) + topPageSize - 1) / topPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	_, err = bot.API.SendTextWithKeybord(id, bot.topToString(scores, window, page, pages), topButtons(page, pages))
+	if err != nil {
+		return fmt.Errorf("can't send message: %s", err)
+	}
+
+	err = bot.DB.SetTopPage(id, window, page)
+	if err != nil {
+		return fmt.Errorf("can't set top: %s", err)
+	}
+	err = bot.DB.SetProcessor(id, TopProcessor)
+	if err != nil {
+		return fmt.Errorf("can't set processor: %s", err)
+	}
+	return nil
+}
+
+func (bot *Bot) feedbackTop(update *telegram.Update) error {
+	if update.Message == nil {
+		return fmt.Errorf("feedbackTop error: %s", telegram.ErrAPINoMessage)
+	}
+
+	text := update.Message.Text
+	id := update.Message.Chat.ID
+
+	window, page, err := bot.DB.GetTopPage(id)
+	if err != nil {
+		return fmt.Errorf("can't get top: %s", err)
+	}
+
+	switch text {
+	case Next:
+		return bot.sendTop(id, window, page+1)
+	case Prev:
+		return bot.sendTop(id, window, page-1)
+	case Back:
+		return bot.start(id, bot.messages().WhatSend)
+	default:
+		return bot.start(id, bot.messages().BadThing)
+	}
+}
+
+func (bot *Bot) topToString(scores []database.Score, window string, page, pages int) string {
+	str := fmt.Sprintf(bot.messages().TopHeader, topWindows[window].title, page+1, pages) + "\n\n"
+
+	from := page * topPageSize
+	to := from + topPageSize
+	if to > len(scores) {
+		to = len(scores)
+	}
+	for i, score := range scores[from:to] {
+		str += strconv.Itoa(from+i+1) + ". #" + score.QuoteID + " — " + strconv.Itoa(score.Score) + "\n" + bot.quoteLink(score.QuoteID) + "\n"
+	}
+
+	return str
+}
+
+func topButtons(page, pages int) telegram.ReplyKeyboardMarkup {
+	navigation := make([]string, 0, 2)
+	if page > 0 {
+		navigation = append(navigation, Prev)
+	}
+	if page < pages-1 {
+		navigation = append(navigation, Next)
+	}
+
+	if len(navigation) == 0 {
+		return telegram.NewReplyKeyboardMarkup([][]string{{Back}})
+	}
+	return telegram.NewReplyKeyboardMarkup([][]string{navigation, {Back}})
+}
@@ -0,0 +1,172 @@
+// Command bashbot-cli runs dialog logic of the bot in a terminal. Lines of
+// stdin are sent to the bot as messages, replies and reply keyboard are
+// printed to stdout. ":N" presses N-th button of the current keyboard.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bot"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/telegram"
+)
+
+// console is a bot.Messenger which writes to terminal
+type console struct {
+	mu       sync.Mutex
+	out      io.Writer
+	messages int
+	keyboard [][]telegram.KeyboardButton
+}
+
+func (c *console) GetMe() (telegram.User, error) {
+	return telegram.User{FirstName: "Bash", UserName: "bashbot_cli"}, nil
+}
+
+func (c *console) SendText(chatID int, text string) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.print(chatID, text), nil
+}
+
+func (c *console) SendTextWithKeybord(chatID int, text string, keybord telegram.ReplyKeyboardMarkup) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyboard = keybord.Keyboard
+	return c.print(chatID, text), nil
+}
+
+func (c *console) SendTextWithoutKeybord(chatID int, text string) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyboard = nil
+	return c.print(chatID, text), nil
+}
+
+func (c *console) EditMessageText(chatID int, messageID int, text string) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.out, "\n(message %d edited)\n%s\n", messageID, text)
+	return telegram.Message{MessageID: messageID, Chat: &telegram.Chat{ID: chatID}, Text: text}, nil
+}
+
+func (c *console) SendPhoto(chatID int, photo string, caption string, keybord *telegram.ReplyKeyboardMarkup) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if keybord != nil {
+		c.keyboard = keybord.Keyboard
+	}
+	return c.print(chatID, "[photo "+photo+"]\n"+caption), nil
+}
+
+func (c *console) UploadPhoto(chatID int, name string, data []byte, caption string, keybord *telegram.ReplyKeyboardMarkup) (telegram.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if keybord != nil {
+		c.keyboard = keybord.Keyboard
+	}
+	return c.print(chatID, fmt.Sprintf("[photo %s, %d bytes]\n%s", name, len(data), caption)), nil
+}
+
+// print writes message and current keyboard, buttons are numbered for ":N"
+func (c *console) print(chatID int, text string) telegram.Message {
+	c.messages++
+
+	fmt.Fprintf(c.out, "\n%s\n", text)
+	n := 1
+	for _, row := range c.keyboard {
+		buttons := make([]string, len(row))
+		for i, button := range row {
+			buttons[i] = fmt.Sprintf("[%d: %s]", n, button.Text)
+			n++
+		}
+		fmt.Fprintln(c.out, strings.Join(buttons, " "))
+	}
+
+	return telegram.Message{
+		MessageID: c.messages,
+		Chat:      &telegram.Chat{ID: chatID},
+		Text:      text,
+	}
+}
+
+// button returns text of n-th button of current keyboard
+func (c *console) button(n int) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n < 1 {
+		return "", false
+	}
+	for _, row := range c.keyboard {
+		if n <= len(row) {
+			return row[n-1].Text, true
+		}
+		n -= len(row)
+	}
+	return "", false
+}
+
+func main() {
+	chatID := flag.Int("chat-id", 1, "id of chat and user of messages")
+	logLevel := flag.String("log-level", "warn", "log level")
+	sourceFile := flag.String("source-file", "", "file with JSON quotes per line, bash.im is used if empty")
+	flag.Parse()
+
+	config := bot.DefaultConfig()
+	config.LogLevel = *logLevel
+	// console runs are not kept
+	config.SearchIndexFile = ""
+	if *sourceFile != "" {
+		config.Source = bot.StaticSource
+		config.SourceFile = *sourceFile
+	}
+
+	source, err := bot.NewSource(config, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	out := &console{out: os.Stdout}
+	b, err := bot.New(out, database.NewMemory(), source, config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	user := &telegram.User{ID: *chatID, FirstName: "console"}
+	chat := &telegram.Chat{ID: *chatID, Type: "private"}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for updateID := 1; scanner.Scan(); updateID++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, ":") {
+			if n, err := strconv.Atoi(text[1:]); err == nil {
+				if button, ok := out.button(n); ok {
+					text = button
+				}
+			}
+		}
+
+		update := &telegram.Update{
+			UpdateID: updateID,
+			Message: &telegram.Message{
+				MessageID: updateID,
+				From:      user,
+				Chat:      chat,
+				Text:      text,
+			},
+		}
+		if err := b.HandleUpdate(update); err != nil {
+			logging.Errorf("%s", err)
+		}
+	}
+}
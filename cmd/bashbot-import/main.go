@@ -0,0 +1,115 @@
+// Command bashbot-import loads quotes into corpus kept in tarantool. Files
+// are either JSON lines with id, text, rating and date (RFC 3339) of quote,
+// or pages of bash.im saved to disk.
+//
+//	bashbot-import [--db-config db.yml] [--format jsonl|html] files...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
+)
+
+// Formats of files
+const (
+	jsonlFormat = "jsonl"
+	htmlFormat  = "html"
+)
+
+func main() {
+	dbConfigPath := flag.String("db-config", "", "path to database config (default "+database.ConfigPath+")")
+	format := flag.String("format", "", "format of files, jsonl or html, guessed by extension if empty")
+	truncate := flag.Bool("truncate", false, "remove all quotes from corpus before import")
+	dbFlags := helper.NewFlags(flag.CommandLine, "db-", &database.Config{})
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "no files to import")
+		os.Exit(2)
+	}
+
+	// default file may be missing, the given one must exist
+	path, required := *dbConfigPath, *dbConfigPath != ""
+	if !required {
+		path = database.ConfigPath
+	}
+	dbConfig, err := database.LoadConfig(path, required, dbFlags)
+	if err != nil {
+		fail(err)
+	}
+
+	db, err := database.NewTarantool(dbConfig)
+	if err != nil {
+		fail(err)
+	}
+
+	if *truncate {
+		if err := db.TruncateCorpus(); err != nil {
+			fail(err)
+		}
+	}
+
+	corpus := database.NewCorpus(db)
+	total := 0
+	for _, path := range flag.Args() {
+		n, err := importFile(corpus, path, *format)
+		if err != nil {
+			fail(fmt.Errorf("%s: %s", path, err))
+		}
+		fmt.Printf("%s: %d quotes\n", path, n)
+		total += n
+	}
+	fmt.Printf("imported %d quotes\n", total)
+}
+
+func importFile(corpus *database.Corpus, path string, format string) (int, error) {
+	if format == "" {
+		format = jsonlFormat
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+			format = htmlFormat
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var quotes []bash.Quote
+	switch format {
+	case jsonlFormat:
+		quotes, err = bash.ReadQuotes(file)
+	case htmlFormat:
+		quotes, err = bash.ParseQuotes(file)
+	default:
+		return 0, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, quote := range quotes {
+		if quote.ID == "" || quote.Text == "" {
+			continue
+		}
+		if err := corpus.PutQuote(quote); err != nil {
+			return n, fmt.Errorf("quote %s: %s", quote.ID, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
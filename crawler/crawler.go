@@ -0,0 +1,213 @@
+// Package crawler mirrors listings of bash.im into local quote store
+package crawler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
+)
+
+var (
+	crawledPages = metrics.NewCounter("bash_crawl_pages_total",
+		"Pages of listings loaded by crawler", "listing")
+	crawledQuotes = metrics.NewCounter("bash_crawl_quotes_total",
+		"Quotes stored by crawler", "listing")
+	crawlFailures = metrics.NewCounter("bash_crawl_failures_total",
+		"Failed crawls of listing pages", "listing")
+)
+
+// Corpus is a local quote store, quotes with known id are replaced
+type Corpus interface {
+	PutQuote(quote bash.Quote) error
+}
+
+// Store keeps crawl cursors and rating snapshots
+type Store interface {
+	GetCrawlCursor(listing string) (string, error)
+	SetCrawlCursor(listing string, token string) error
+	AddRatingSnapshot(quoteID string, at time.Time, rating int) error
+}
+
+// Config of crawler
+type Config struct {
+	// Interval between crawls
+	Interval time.Duration
+	// Delay between page requests
+	Delay time.Duration
+	// Pages is a number of pages of listing loaded per crawl
+	Pages    int
+	Listings []string
+}
+
+// Crawler regularly walks listings of source. Every crawl loads the first
+// page of listing for new quotes and then goes deeper from cursor left by
+// the previous crawl, so the whole listing is mirrored over time.
+type Crawler struct {
+	source bash.Browser
+	corpus Corpus
+	store  Store
+	config Config
+
+	// Client is optional, crawl is skipped while its circuit is open
+	Client *bash.Client
+
+	mu        sync.Mutex
+	lastCrawl time.Time
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// New creates crawler which puts quotes of source to corpus, Start must be
+// called to run it
+func New(source bash.Browser, corpus Corpus, store Store, config Config) *Crawler {
+	return &Crawler{
+		source: source,
+		corpus: corpus,
+		store:  store,
+		config: config,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start begins crawling in background
+func (c *Crawler) Start() {
+	go c.run()
+}
+
+// Stop stops crawling and waits until the page being loaded is stored
+func (c *Crawler) Stop() {
+	close(c.quit)
+	<-c.done
+}
+
+// LastCrawl returns time when the last crawl was finished
+func (c *Crawler) LastCrawl() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastCrawl
+}
+
+func (c *Crawler) run() {
+	defer close(c.done)
+	for {
+		c.crawl()
+
+		select {
+		case <-c.quit:
+			return
+		case <-time.After(c.config.Interval):
+		}
+	}
+}
+
+func (c *Crawler) crawl() {
+	if c.Client != nil && c.Client.BreakerState() == bash.BreakerOpen {
+		logging.Debugf("crawl skipped, circuit is open")
+		return
+	}
+
+	for i, listing := range c.config.Listings {
+		if i > 0 && !c.wait() {
+			return
+		}
+		if !c.crawlListing(listing) {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.lastCrawl = time.Now()
+	c.mu.Unlock()
+}
+
+// crawlListing loads pages of listing and reports false if crawler was stopped
+func (c *Crawler) crawlListing(listing string) bool {
+	log := logging.With("listing", listing)
+
+	cursor, err := c.store.GetCrawlCursor(listing)
+	if err != nil && err != database.ErrEmpty {
+		log.Errorf("can't get crawl cursor: %s", err)
+		return true
+	}
+
+	// the first page brings new quotes and fresh ratings
+	page, err := c.fetch(listing, "")
+	if err != nil {
+		return true
+	}
+	if cursor == "" {
+		cursor = page.Next
+	}
+
+	for i := 1; i < c.config.Pages && cursor != ""; i++ {
+		if !c.wait() {
+			return false
+		}
+
+		page, err = c.fetch(listing, cursor)
+		if status, ok := err.(*bash.StatusError); ok && status.Code < 500 {
+			// page of cursor is gone, walk starts again next time
+			page = bash.Page{}
+		} else if err != nil {
+			return true
+		}
+
+		cursor = page.Next
+		if err := c.store.SetCrawlCursor(listing, cursor); err != nil {
+			log.Errorf("can't set crawl cursor: %s", err)
+			return true
+		}
+	}
+	return true
+}
+
+func (c *Crawler) fetch(listing string, token string) (bash.Page, error) {
+	page, err := c.source.Browse(listing, token)
+	if err != nil {
+		crawlFailures.Inc(listing)
+		logging.With("listing", listing).Warnf("can't crawl page %q: %s", token, err)
+		return page, err
+	}
+	crawledPages.Inc(listing)
+	c.put(listing, page.Quotes)
+	return page, nil
+}
+
+// put stores quotes and their ratings
+func (c *Crawler) put(listing string, quotes []bash.Quote) {
+	now := time.Now()
+	for _, quote := range quotes {
+		if quote.ID == "" {
+			continue
+		}
+		log := logging.With("listing", listing, "quote_id", quote.ID)
+
+		if err := c.corpus.PutQuote(quote); err != nil {
+			log.Errorf("can't store quote: %s", err)
+			continue
+		}
+		crawledQuotes.Inc(listing)
+
+		if quote.Rating.Known {
+			if err := c.store.AddRatingSnapshot(quote.ID, now, quote.Rating.Value); err != nil {
+				log.Errorf("can't store rating: %s", err)
+			}
+		}
+	}
+}
+
+// wait makes pause between requests and reports false if crawler was stopped
+func (c *Crawler) wait() bool {
+	select {
+	case <-c.quit:
+		return false
+	case <-time.After(c.config.Delay):
+		return true
+	}
+}
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+)
+
+// pagedListing has pages "", "1", "2"... of two quotes each
+type pagedListing struct {
+	pages    int
+	ids      func(page, i int) string
+	requests []string
+}
+
+func (l *pagedListing) Browse(listing string, token string) (bash.Page, error) {
+	l.requests = append(l.requests, token)
+	n, _ := strconv.Atoi(token)
+
+	page := bash.Page{Token: token}
+	for i := 0; i < 2; i++ {
+		quote := bash.Quote{ID: l.ids(n, i), Text: "text"}
+		quote.Rating = bash.Rating{Value: n, Known: true}
+		page.Quotes = append(page.Quotes, quote)
+	}
+	if n+1 < l.pages {
+		page.Next = strconv.Itoa(n + 1)
+	}
+	return page, nil
+}
+
+type memoryCorpus map[string]bash.Quote
+
+func (c memoryCorpus) PutQuote(quote bash.Quote) error {
+	c[quote.ID] = quote
+	return nil
+}
+
+type memoryStore struct {
+	cursors   map[string]string
+	snapshots int
+}
+
+func (s *memoryStore) GetCrawlCursor(listing string) (string, error) {
+	cursor, ok := s.cursors[listing]
+	if !ok {
+		return "", database.ErrEmpty
+	}
+	return cursor, nil
+}
+
+func (s *memoryStore) SetCrawlCursor(listing string, token string) error {
+	s.cursors[listing] = token
+	return nil
+}
+
+func (s *memoryStore) AddRatingSnapshot(quoteID string, at time.Time, rating int) error {
+	s.snapshots++
+	return nil
+}
+
+func numbered(page, i int) string {
+	return strconv.Itoa(page*10 + i + 1)
+}
+
+func abyss(page, i int) string {
+	return "AB" + strconv.Itoa(page) + "C" + strconv.Itoa(i)
+}
+
+func TestCrawlListing(t *testing.T) {
+	tests := []struct {
+		name     string
+		ids      func(page, i int) string
+		pages    int
+		crawls   int
+		requests []string
+		quotes   int
+		cursor   string
+	}{
+		{"first crawl", numbered, 10, 1, []string{"", "1", "2"}, 6, "3"},
+		{"crawl resumes from cursor", numbered, 10, 2, []string{"", "1", "2", "", "3", "4"}, 10, "5"},
+		{"walk ends on the last page", numbered, 4, 2, []string{"", "1", "2", "", "3"}, 8, ""},
+		{"abyss quotes are stored", abyss, 2, 1, []string{"", "1"}, 4, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &pagedListing{pages: test.pages, ids: test.ids}
+			corpus := memoryCorpus{}
+			store := &memoryStore{cursors: map[string]string{}}
+			c := New(source, corpus, store, Config{Pages: 3, Listings: []string{bash.ListingNew}})
+
+			for i := 0; i < test.crawls; i++ {
+				if !c.crawlListing(bash.ListingNew) {
+					t.Fatal("crawl is stopped")
+				}
+			}
+
+			if got, want := strings.Join(source.requests, ","), strings.Join(test.requests, ","); got != want {
+				t.Errorf("requested pages %q, want %q", got, want)
+			}
+			if len(corpus) != test.quotes {
+				t.Errorf("stored %d quotes, want %d", len(corpus), test.quotes)
+			}
+			if store.snapshots != 2*len(test.requests) {
+				t.Errorf("stored %d ratings, want %d", store.snapshots, 2*len(test.requests))
+			}
+			if cursor := store.cursors[bash.ListingNew]; cursor != test.cursor {
+				t.Errorf("cursor is %q, want %q", cursor, test.cursor)
+			}
+		})
+	}
+}
+
+func TestStopWaitsForCrawl(t *testing.T) {
+	source := &pagedListing{pages: 10, ids: numbered}
+	store := &memoryStore{cursors: map[string]string{}}
+	c := New(source, memoryCorpus{}, store, Config{
+		Interval: time.Hour,
+		Delay:    10 * time.Millisecond,
+		Pages:    100,
+		Listings: []string{bash.ListingNew},
+	})
+	c.Start()
+	time.Sleep(25 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("crawler is not stopped")
+	}
+
+	requests := len(source.requests)
+	time.Sleep(30 * time.Millisecond)
+	if len(source.requests) != requests {
+		t.Error("pages are loaded after Stop")
+	}
+}
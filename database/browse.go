@@ -0,0 +1,33 @@
+package database
+
+import (
+	tarantool "github.com/tarantool/go-tarantool"
+)
+
+// SetBrowse stores listing, page and index of quote shown in chat
+func (db *Tarantool) SetBrowse(chatID int, listing string, page string, index int) error {
+	_, err := db.connection.Replace(browseDB, []interface{}{chatID, listing, page, index})
+	return err
+}
+
+// GetBrowse returns listing, page and index of quote shown in chat
+func (db *Tarantool) GetBrowse(chatID int) (string, string, int, error) {
+	resp, err := db.connection.Select(browseDB, primary, 0, 1, tarantool.IterEq, []interface{}{chatID})
+	if err != nil {
+		return "", "", -1, err
+	}
+
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 4 {
+		return "", "", -1, ErrEmpty
+	}
+
+	tuple := resp.Tuples()[0]
+	listing, okl := tuple[1].(string)
+	page, okp := tuple[2].(string)
+	index, oki := toInt(tuple[3])
+	if !okl || !okp || !oki {
+		return "", "", -1, ErrIncorrectType
+	}
+
+	return listing, page, index, nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"time"
+
+	tarantool "github.com/tarantool/go-tarantool"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// StoreQuote saves quote of cache with time it was cached at
+func (db *Tarantool) StoreQuote(quote bash.Quote, at time.Time) error {
+	tuple := append([]interface{}{quote.ID, uint64(at.Unix())}, quoteFields(quote)...)
+	_, err := db.connection.Replace(cacheDB, tuple)
+	return err
+}
+
+// LoadQuote returns cached quote and time it was cached at
+func (db *Tarantool) LoadQuote(id string) (bash.Quote, time.Time, error) {
+	resp, err := db.connection.Select(cacheDB, primary, 0, 1, tarantool.IterEq, []interface{}{id})
+	if err != nil {
+		return bash.Quote{}, time.Time{}, err
+	}
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 2 {
+		return bash.Quote{}, time.Time{}, ErrEmpty
+	}
+
+	tuple := resp.Tuples()[0]
+	at, ok := toInt(tuple[1])
+	if !ok {
+		return bash.Quote{}, time.Time{}, ErrIncorrectType
+	}
+	quote, err := fieldsQuote(tuple[2:])
+	if err != nil {
+		return bash.Quote{}, time.Time{}, err
+	}
+	quote.ID = id
+	return quote, time.Unix(int64(at), 0), nil
+}
+
+// TruncateCache func  (db *Tarantool)
+func (db *Tarantool) TruncateCache() error {
+	return db.truncateSpace(cacheDB)
+}
@@ -2,6 +2,9 @@ package database
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
 )
 
 //Database errors
@@ -10,9 +13,10 @@ var (
 	ErrIncorrectType = errors.New("incorrect type")
 )
 
-// Path to db config
+// Config sources
 const (
 	ConfigPath = "db.yml"
+	EnvPrefix  = "BASHBOT_DB_"
 )
 
 // Config of db
@@ -25,3 +29,49 @@ type Config struct {
 	Reconnect     int    `yaml:"reconnect"`
 	MaxReconnects uint   `yaml:"max_reconnects"`
 }
+
+// DefaultConfig returns config with default values
+func DefaultConfig() Config {
+	return Config{
+		Host:          "127.0.0.1",
+		Port:          "3301",
+		Timeout:       5,
+		Reconnect:     1,
+		MaxReconnects: 3,
+	}
+}
+
+// LoadConfig layers defaults, yaml file at path, BASHBOT_DB_* environment
+// variables and flags, then validates the result
+func LoadConfig(path string, required bool, flags *helper.Flags) (Config, error) {
+	config := DefaultConfig()
+
+	err := helper.LoadConfig(path, required, EnvPrefix, &config)
+	if err != nil {
+		return Config{}, err
+	}
+	err = flags.Apply(&config)
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid db config: %s", err)
+	}
+	return config, nil
+}
+
+// Validate checks config values
+func (config *Config) Validate() error {
+	if config.Host == "" || config.Port == "" {
+		return errors.New("host and port are required")
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("timeout must be positive number of seconds, got %d", config.Timeout)
+	}
+	if config.Reconnect < 0 {
+		return fmt.Errorf("reconnect can't be negative, got %d", config.Reconnect)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	tarantool "github.com/tarantool/go-tarantool"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	searchindex "github.com/AnisimoffNikita/go_bash_telgram_bot/index"
+)
+
+// corpusBatch is a number of quotes read at once while scanning corpus
+const corpusBatch = 1000
+
+// randomCorpusQuotes is a number of quotes returned by Random
+const randomCorpusQuotes = 50
+
+// rawQueryRandom returns up to n random tuples of space
+const rawQueryRandom = `local n = ...
+local index = box.space.%s.index.primary
+local result = {}
+for i = 1, n do
+	local tuple = index:random(math.random(0, 2147483647))
+	if tuple ~= nil then
+		table.insert(result, tuple)
+	end
+end
+return result`
+
+// Corpus is a quote source over quotes imported into tarantool
+type Corpus struct {
+	db *Tarantool
+
+	// MaxResults limits number of quotes returned by Search
+	MaxResults int
+
+	mu sync.Mutex
+	// index is used by Search, it is built from corpus on the first search
+	index *searchindex.Index
+}
+
+// NewCorpus creates corpus stored in db
+func NewCorpus(db *Tarantool) *Corpus {
+	return &Corpus{db: db, MaxResults: 100}
+}
+
+// PutQuote adds quote to corpus or replaces it, quotes of the abyss which
+// have no number yet are kept apart by their id
+func (c *Corpus) PutQuote(quote bash.Quote) error {
+	if quote.ID == "" {
+		return errors.New("quote has no id")
+	}
+
+	space, key := corpusKey(quote.ID)
+	_, err := c.db.connection.Replace(space, append([]interface{}{key}, quoteFields(quote)...))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index != nil {
+		return c.index.Add(quote)
+	}
+	return nil
+}
+
+// SetIndex makes Search use idx which must have every quote of corpus
+func (c *Corpus) SetIndex(idx *searchindex.Index) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index = idx
+}
+
+// corpusKey returns space and key of quote with id
+func corpusKey(id string) (string, interface{}) {
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return corpusDB, n
+	}
+	return abyssDB, id
+}
+
+// Random returns random quotes of corpus
+func (c *Corpus) Random() ([]bash.Quote, error) {
+	query := fmt.Sprintf(rawQueryRandom, corpusDB)
+	resp, err := c.db.connection.Eval(query, []interface{}{randomCorpusQuotes})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+
+	tuples, ok := resp.Data[0].([]interface{})
+	if !ok {
+		return nil, ErrIncorrectType
+	}
+
+	quotes := make([]bash.Quote, 0, len(tuples))
+	for _, t := range tuples {
+		tuple, ok := t.([]interface{})
+		if !ok {
+			return nil, ErrIncorrectType
+		}
+		quote, err := corpusQuote(tuple)
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// Search returns quotes matching query
+func (c *Corpus) Search(query string) ([]bash.Quote, error) {
+	idx, err := c.searchIndex()
+	if err != nil {
+		return nil, err
+	}
+	return idx.Search(query, c.MaxResults), nil
+}
+
+// searchIndex returns index of corpus, it is built on the first call
+func (c *Corpus) searchIndex() (*searchindex.Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.index != nil {
+		return c.index, nil
+	}
+
+	idx := searchindex.New()
+	var addErr error
+	err := c.Each(func(quote bash.Quote) bool {
+		addErr = idx.Add(quote)
+		return addErr == nil
+	})
+	if err == nil {
+		err = addErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't index corpus: %s", err)
+	}
+	c.index = idx
+	return idx, nil
+}
+
+// ByID returns quote by id
+func (c *Corpus) ByID(id string) (bash.Quote, error) {
+	if id == "" {
+		return bash.Quote{}, bash.ErrNotFound
+	}
+
+	space, key := corpusKey(id)
+	resp, err := c.db.connection.Select(space, primary, 0, 1, tarantool.IterEq, []interface{}{key})
+	if err != nil {
+		return bash.Quote{}, err
+	}
+	if len(resp.Tuples()) == 0 {
+		return bash.Quote{}, bash.ErrNotFound
+	}
+	return corpusQuote(resp.Tuples()[0])
+}
+
+// Vote is not supported
+func (c *Corpus) Vote(id string, vote bash.Vote) (bash.VoteResult, error) {
+	return bash.VoteResult{Status: bash.VoteRejected}, bash.ErrUnsupported
+}
+
+// Capabilities of corpus
+func (c *Corpus) Capabilities() bash.Capabilities {
+	return bash.CanRandom | bash.CanSearch | bash.CanByID
+}
+
+// Each calls f for every quote of corpus and then of the abyss until it returns false
+func (c *Corpus) Each(f func(quote bash.Quote) bool) error {
+	more := true
+	err := c.scan(corpusDB, uint64(0), func(quote bash.Quote) bool {
+		more = f(quote)
+		return more
+	})
+	if err != nil || !more {
+		return err
+	}
+	return c.scan(abyssDB, "", f)
+}
+
+// scan calls f for quotes of space in order of id until it returns false,
+// first is the least key of space
+func (c *Corpus) scan(space string, first interface{}, f func(quote bash.Quote) bool) error {
+	last := first
+	iterator := tarantool.IterGe
+	for {
+		resp, err := c.db.connection.Select(space, primary, 0, corpusBatch, iterator, []interface{}{last})
+		if err != nil {
+			return err
+		}
+
+		for _, tuple := range resp.Tuples() {
+			quote, err := corpusQuote(tuple)
+			if err != nil {
+				return err
+			}
+			if !f(quote) {
+				return nil
+			}
+			_, last = corpusKey(quote.ID)
+		}
+
+		if len(resp.Tuples()) < corpusBatch {
+			return nil
+		}
+		iterator = tarantool.IterGt
+	}
+}
+
+func corpusQuote(tuple []interface{}) (bash.Quote, error) {
+	if len(tuple) < 1 {
+		return bash.Quote{}, ErrEmpty
+	}
+
+	var id string
+	if n, ok := toInt(tuple[0]); ok {
+		id = strconv.Itoa(n)
+	} else if id, ok = tuple[0].(string); !ok {
+		return bash.Quote{}, ErrIncorrectType
+	}
+
+	quote, err := fieldsQuote(tuple[1:])
+	quote.ID = id
+	return quote, err
+}
+
+// TruncateCorpus removes numbered quotes and quotes of the abyss
+func (db *Tarantool) TruncateCorpus() error {
+	if err := db.truncateSpace(corpusDB); err != nil {
+		return err
+	}
+	return db.truncateSpace(abyssDB)
+}
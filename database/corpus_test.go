@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	searchindex "github.com/AnisimoffNikita/go_bash_telgram_bot/index"
+)
+
+func TestCorpusSearchUsesIndex(t *testing.T) {
+	idx := searchindex.New()
+	err := idx.Add(
+		bash.Quote{ID: "1", Text: "кот сидит на окне"},
+		bash.Quote{ID: "2", Text: "коты спят"},
+		bash.Quote{ID: "3", Text: "собака лает"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// corpus without connection shows that tarantool is not scanned
+	corpus := NewCorpus(nil)
+	corpus.SetIndex(idx)
+
+	tests := []struct {
+		query      string
+		maxResults int
+		want       int
+	}{
+		{"кот", 100, 2},
+		{"кот", 1, 1},
+		{"собака", 100, 1},
+		{"рыба", 100, 0},
+	}
+	for _, test := range tests {
+		corpus.MaxResults = test.maxResults
+		quotes, err := corpus.Search(test.query)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %s", test.query, err)
+		}
+		if len(quotes) != test.want {
+			t.Errorf("Search(%q) with %d results = %v, want %d quotes", test.query, test.maxResults, quotes, test.want)
+		}
+	}
+}
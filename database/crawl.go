@@ -0,0 +1,85 @@
+package database
+
+import (
+	"time"
+
+	tarantool "github.com/tarantool/go-tarantool"
+)
+
+// RatingSnapshot is a rating of quote on bash.im at some moment
+type RatingSnapshot struct {
+	At     time.Time
+	Rating int
+}
+
+// SetCrawlCursor func  (db *Tarantool)
+func (db *Tarantool) SetCrawlCursor(listing string, token string) error {
+	_, err := db.connection.Replace(crawlDB, []interface{}{listing, token, uint64(time.Now().Unix())})
+	return err
+}
+
+// GetCrawlCursor func  (db *Tarantool)
+func (db *Tarantool) GetCrawlCursor(listing string) (string, error) {
+	resp, err := db.connection.Select(crawlDB, primary, 0, 1, tarantool.IterEq, []interface{}{listing})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 2 {
+		return "", ErrEmpty
+	}
+
+	token, ok := resp.Tuples()[0][1].(string)
+	if !ok {
+		return "", ErrIncorrectType
+	}
+	return token, nil
+}
+
+// AddRatingSnapshot stores rating of quote if it differs from the last stored one
+func (db *Tarantool) AddRatingSnapshot(quoteID string, at time.Time, rating int) error {
+	resp, err := db.connection.Select(ratingsDB, primary, 0, 1, tarantool.IterLe, []interface{}{quoteID, uint64(at.Unix())})
+	if err != nil {
+		return err
+	}
+	if len(resp.Tuples()) > 0 && len(resp.Tuples()[0]) > 2 {
+		last := resp.Tuples()[0]
+		if id, _ := last[0].(string); id == quoteID {
+			if value, ok := toInt(last[2]); ok && value == rating {
+				return nil
+			}
+		}
+	}
+
+	_, err = db.connection.Replace(ratingsDB, []interface{}{quoteID, uint64(at.Unix()), rating})
+	return err
+}
+
+// RatingHistory returns stored ratings of quote, the oldest first
+func (db *Tarantool) RatingHistory(quoteID string) ([]RatingSnapshot, error) {
+	resp, err := db.connection.Select(ratingsDB, primary, 0, maxSelect, tarantool.IterEq, []interface{}{quoteID})
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]RatingSnapshot, 0, len(resp.Tuples()))
+	for _, tuple := range resp.Tuples() {
+		if len(tuple) < 3 {
+			return nil, ErrEmpty
+		}
+		at, ok := toInt(tuple[1])
+		if !ok {
+			return nil, ErrIncorrectType
+		}
+		rating, ok := toInt(tuple[2])
+		if !ok {
+			return nil, ErrIncorrectType
+		}
+		history = append(history, RatingSnapshot{At: time.Unix(int64(at), 0), Rating: rating})
+	}
+	return history, nil
+}
+
+// TruncateRatings func  (db *Tarantool)
+func (db *Tarantool) TruncateRatings() error {
+	return db.truncateSpace(ratingsDB)
+}
@@ -5,8 +5,6 @@ import (
 	"time"
 
 	tarantool "github.com/tarantool/go-tarantool"
-
-	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
 )
 
 // Tarantool connection type
@@ -15,23 +13,73 @@ type Tarantool struct {
 }
 
 const (
-	processorsDB = "tg_bot_processor"
-	savedDB      = "tg_bot_saved"
-	quoteDB      = "tg_bot_quotes"
-	searchDB     = "tg_bot_search"
+	processorsDB  = "tg_bot_processor"
+	savedDB       = "tg_bot_saved"
+	quoteDB       = "tg_bot_quotes"
+	searchDB      = "tg_bot_search"
+	votesDB       = "tg_bot_votes"
+	eventsDB      = "tg_bot_events"
+	topDB         = "tg_bot_top"
+	browseDB      = "tg_bot_browse"
+	sessionsDB    = "tg_bot_sessions"
+	corpusDB      = "tg_bot_corpus"
+	abyssDB       = "tg_bot_abyss"
+	cacheDB       = "tg_bot_cache"
+	savedQuotesDB = "tg_bot_saved_quotes"
+	crawlDB       = "tg_bot_crawl"
+	ratingsDB     = "tg_bot_ratings"
+
+	primary    = "primary"
+	quoteIndex = "quote"
+	timeIndex  = "time"
+
+	maxSelect = 1 << 20
+)
 
-	primary = "primary"
+const (
+	rawQueryCreateSpace = "box.schema.space.create('%s', {if_not_exists = true})"
+	rawQueryCreateIndex = "box.space.%s:create_index('%s', {if_not_exists = true, %s})"
+
+	hashUnsigned = "type = 'hash', parts = {1, 'unsigned'}"
 )
 
-// NewTarantool creates new tarantool connection
-func NewTarantool() (*Tarantool, error) {
+type index struct {
+	name    string
+	options string
+}
 
-	var config Config
-	err := helper.GetYamlConfig(ConfigPath, &config)
-	if err != nil {
-		return nil, fmt.Errorf("no tarantool config: %s", err)
-	}
+var spaces = []struct {
+	name    string
+	indexes []index
+}{
+	{processorsDB, []index{{primary, hashUnsigned}}},
+	{savedDB, []index{{primary, hashUnsigned}}},
+	{quoteDB, []index{{primary, hashUnsigned}}},
+	{searchDB, []index{{primary, hashUnsigned}}},
+	{votesDB, []index{
+		{primary, "type = 'hash', parts = {1, 'unsigned', 2, 'string'}"},
+		{quoteIndex, "type = 'tree', unique = false, parts = {2, 'string'}"},
+	}},
+	{eventsDB, []index{
+		{primary, "type = 'hash', parts = {1, 'unsigned', 2, 'string', 3, 'string'}"},
+		{timeIndex, "type = 'tree', unique = false, parts = {4, 'unsigned'}"},
+	}},
+	{topDB, []index{{primary, hashUnsigned}}},
+	{browseDB, []index{{primary, hashUnsigned}}},
+	{corpusDB, []index{{primary, "type = 'tree', parts = {1, 'unsigned'}"}}},
+	{abyssDB, []index{{primary, "type = 'tree', parts = {1, 'string'}"}}},
+	{cacheDB, []index{{primary, "type = 'hash', parts = {1, 'string'}"}}},
+	{savedQuotesDB, []index{{primary, "type = 'hash', parts = {1, 'string'}"}}},
+	{crawlDB, []index{{primary, "type = 'hash', parts = {1, 'string'}"}}},
+	{ratingsDB, []index{{primary, "type = 'tree', parts = {1, 'string', 2, 'unsigned'}"}}},
+	{sessionsDB, []index{
+		{primary, hashUnsigned},
+		{timeIndex, "type = 'tree', unique = false, parts = {2, 'unsigned'}"},
+	}},
+}
 
+// NewTarantool creates new tarantool connection
+func NewTarantool(config Config) (*Tarantool, error) {
 	opts := tarantool.Opts{
 		Timeout:       time.Duration(config.Timeout) * time.Second,
 		Reconnect:     time.Duration(config.Reconnect) * time.Second,
@@ -40,33 +88,54 @@ func NewTarantool() (*Tarantool, error) {
 		Pass:          config.Pass,
 	}
 
-	connection, err := tarantool.Connect(fmt.Sprintf("%s:%s", config.Host, config.Port), opts)
+	address := fmt.Sprintf("%s:%s", config.Host, config.Port)
+	connection, err := tarantool.Connect(address, opts)
 
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to tarantool: %s", err)
 	}
 
-	rawQueryCreateSpace := "box.schema.space.create('%s')"
-	rawQueryCreateIndex := "box.space.%s:create_index('%s', {type = 'hash',parts = {1, 'unsigned'}})"
-	dbs := []string{processorsDB, savedDB, quoteDB, searchDB}
-
-	for _, db := range dbs {
-		space := connection.Schema.Spaces[db]
-		if space == nil {
-			_, err := connection.Eval(fmt.Sprintf(rawQueryCreateSpace, db), []interface{}{})
+	// spaces and indexes missing after older versions are created, existing ones are kept
+	created := false
+	for _, space := range spaces {
+		existing := connection.Schema.Spaces[space.name]
+		if existing == nil {
+			_, err := connection.Eval(fmt.Sprintf(rawQueryCreateSpace, space.name), []interface{}{})
 			if err != nil {
-				return nil, fmt.Errorf("cannot create space %s: %s", db, err)
+				return nil, fmt.Errorf("cannot create space %s: %s", space.name, err)
+			}
+			created = true
+		}
+		for _, index := range space.indexes {
+			if existing != nil && existing.Indexes[index.name] != nil {
+				continue
 			}
-			_, err = connection.Eval(fmt.Sprintf(rawQueryCreateIndex, db, primary), []interface{}{})
+			_, err = connection.Eval(fmt.Sprintf(rawQueryCreateIndex, space.name, index.name, index.options), []interface{}{})
 			if err != nil {
-				return nil, fmt.Errorf("cannot create index on %s: %s", db, err)
+				return nil, fmt.Errorf("cannot create index %s on %s: %s", index.name, space.name, err)
 			}
+			created = true
+		}
+	}
+
+	// schema is loaded once per connection, so reconnect to see new spaces
+	if created {
+		connection.Close()
+		connection, err = tarantool.Connect(address, opts)
+		if err != nil {
+			return nil, fmt.Errorf("cannot reconnect to tarantool: %s", err)
 		}
 	}
 
 	return &Tarantool{connection: connection}, nil
 }
 
+// Ping checks connection to tarantool
+func (db *Tarantool) Ping() error {
+	_, err := db.connection.Ping()
+	return err
+}
+
 // SetLastQuote func  (db *Tarantool)
 func (db *Tarantool) SetLastQuote(chatID int, quoteID string) error {
 	return db.setString(quoteDB, chatID, quoteID)
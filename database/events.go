@@ -0,0 +1,218 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	tarantool "github.com/tarantool/go-tarantool"
+)
+
+// Event kinds
+const (
+	EventSave = "save"
+	EventVote = "vote"
+)
+
+// maxTopScores limits number of quotes returned by GetTop
+const maxTopScores = 1000
+
+// rawQueryAddEvent replaces event unless it repeats the stored one, so
+// repeated event keeps its time and changed one gets the new time
+const rawQueryAddEvent = `local user_id, quote_id, kind, at, weight = ...
+local space = box.space.%s
+local old = space:get({user_id, quote_id, kind})
+if old ~= nil and old[5] == weight then
+	return
+end
+space:replace({user_id, quote_id, kind, at, weight})`
+
+// rawQueryTop sums events since time the way contributions do and returns
+// up to limit best {quote, score} pairs
+const rawQueryTop = `local from, limit, vote, save = ...
+local contributions = {}
+for _, t in box.space.%s.index.%s:pairs({from}, {iterator = 'GE'}) do
+	local key = t[1] .. ':' .. t[2]
+	local c = contributions[key]
+	if c == nil then
+		c = {quote = t[2], kinds = {}}
+		contributions[key] = c
+	end
+	c.kinds[t[3]] = t[5]
+end
+local scores = {}
+for _, c in pairs(contributions) do
+	local weight = c.kinds[vote]
+	if weight == nil then
+		weight = c.kinds[save] or 0
+	end
+	scores[c.quote] = (scores[c.quote] or 0) + weight
+end
+local top = {}
+for quote, score in pairs(scores) do
+	if score > 0 then
+		table.insert(top, {quote, score})
+	end
+end
+table.sort(top, function(a, b)
+	if a[2] ~= b[2] then
+		return a[2] > b[2]
+	end
+	return a[1] < b[1]
+end)
+local result = setmetatable({}, {__serialize = 'array'})
+for i = 1, math.min(limit, #top) do
+	result[i] = top[i]
+end
+return result`
+
+// Score of quote in top
+type Score struct {
+	QuoteID string
+	Score   int
+}
+
+// AddEvent stores user's event for quote, repeated event of the same kind
+// keeps its time unless weight is changed
+func (db *Tarantool) AddEvent(userID int, quoteID string, kind string, weight int) error {
+	query := fmt.Sprintf(rawQueryAddEvent, eventsDB)
+	_, err := db.connection.Eval(query, []interface{}{userID, quoteID, kind, uint64(time.Now().Unix()), weight})
+	return err
+}
+
+// RemoveEvent func  (db *Tarantool)
+func (db *Tarantool) RemoveEvent(userID int, quoteID string, kind string) error {
+	_, err := db.connection.Delete(eventsDB, primary, []interface{}{userID, quoteID, kind})
+	return err
+}
+
+// GetTop sums weights of events since given time, best quotes go first.
+// Events are summed by tarantool, only up to maxTopScores scores are sent back.
+func (db *Tarantool) GetTop(since time.Time) ([]Score, error) {
+	from := uint64(0)
+	if !since.IsZero() {
+		from = uint64(since.Unix())
+	}
+
+	query := fmt.Sprintf(rawQueryTop, eventsDB, timeIndex)
+	resp, err := db.connection.Eval(query, []interface{}{from, maxTopScores, EventVote, EventSave})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+
+	pairs, ok := resp.Data[0].([]interface{})
+	if !ok {
+		return nil, ErrIncorrectType
+	}
+
+	top := make([]Score, 0, len(pairs))
+	for _, p := range pairs {
+		pair, ok := p.([]interface{})
+		if !ok || len(pair) < 2 {
+			return nil, ErrIncorrectType
+		}
+		quoteID, okq := pair[0].(string)
+		score, oks := toInt(pair[1])
+		if !okq || !oks {
+			return nil, ErrIncorrectType
+		}
+		top = append(top, Score{QuoteID: quoteID, Score: score})
+	}
+	return top, nil
+}
+
+type userQuote struct {
+	userID  int
+	quoteID string
+}
+
+// contributions keeps weights of events of every user and quote by kind
+type contributions map[userQuote]map[string]int
+
+func (c contributions) add(userID int, quoteID string, kind string, weight int) {
+	key := userQuote{userID, quoteID}
+	if c[key] == nil {
+		c[key] = make(map[string]int)
+	}
+	c[key][kind] = weight
+}
+
+// scores sums contributions, every user counts once for a quote: saving is
+// a plus vote, so it is not counted when the user voted for the quote
+func (c contributions) scores() map[string]int {
+	scores := make(map[string]int)
+	for key, kinds := range c {
+		if weight, ok := kinds[EventVote]; ok {
+			scores[key.quoteID] += weight
+		} else {
+			scores[key.quoteID] += kinds[EventSave]
+		}
+	}
+	return scores
+}
+
+// topScores sorts positive scores, best quotes go first, only maxTopScores
+// of them are returned
+func topScores(scores map[string]int) []Score {
+	top := make([]Score, 0, len(scores))
+	for quoteID, score := range scores {
+		if score > 0 {
+			top = append(top, Score{QuoteID: quoteID, Score: score})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Score != top[j].Score {
+			return top[i].Score > top[j].Score
+		}
+		return top[i].QuoteID < top[j].QuoteID
+	})
+	if len(top) > maxTopScores {
+		top = top[:maxTopScores]
+	}
+	return top
+}
+
+// TruncateEvents func  (db *Tarantool)
+func (db *Tarantool) TruncateEvents() error {
+	return db.truncateSpace(eventsDB)
+}
+
+// SetTopPage stores top window and page shown in chat
+func (db *Tarantool) SetTopPage(chatID int, window string, page int) error {
+	_, err := db.connection.Upsert(topDB, []interface{}{chatID, window, page},
+		[]interface{}{
+			[]interface{}{"=", 1, window},
+			[]interface{}{"=", 2, page}})
+	return err
+}
+
+// GetTopPage returns top window and page shown in chat
+func (db *Tarantool) GetTopPage(chatID int) (string, int, error) {
+	resp, err := db.connection.Select(topDB, primary, 0, 1, tarantool.IterEq, []interface{}{chatID})
+	if err != nil {
+		return "", -1, err
+	}
+
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 3 {
+		return "", -1, ErrEmpty
+	}
+
+	window, ok := resp.Tuples()[0][1].(string)
+	if !ok {
+		return "", -1, ErrIncorrectType
+	}
+	page, ok := toInt(resp.Tuples()[0][2])
+	if !ok {
+		return "", -1, ErrIncorrectType
+	}
+
+	return window, page, nil
+}
+
+// TruncateTop func  (db *Tarantool)
+func (db *Tarantool) TruncateTop() error {
+	return db.truncateSpace(topDB)
+}
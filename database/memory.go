@@ -0,0 +1,388 @@
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// Memory is a Store which keeps everything in process memory
+type Memory struct {
+	mu         sync.Mutex
+	lastQuotes map[int]string
+	processors map[int]string
+	searches   map[int]search
+	saved      map[int]map[string]bool
+	quotes     map[string]bash.Quote
+	votes      map[vote]int
+	events     map[event]eventValue
+	tops       map[int]topPage
+	browses    map[int]browse
+	activity   map[int]time.Time
+}
+
+type search struct {
+	req     string
+	index   int
+	quoteID string
+}
+
+type vote struct {
+	userID  int
+	quoteID string
+}
+
+type event struct {
+	userID  int
+	quoteID string
+	kind    string
+}
+
+type eventValue struct {
+	time   time.Time
+	weight int
+}
+
+type topPage struct {
+	window string
+	page   int
+}
+
+type browse struct {
+	listing string
+	page    string
+	index   int
+}
+
+// NewMemory creates empty memory store
+func NewMemory() *Memory {
+	return &Memory{
+		lastQuotes: make(map[int]string),
+		processors: make(map[int]string),
+		searches:   make(map[int]search),
+		saved:      make(map[int]map[string]bool),
+		quotes:     make(map[string]bash.Quote),
+		votes:      make(map[vote]int),
+		events:     make(map[event]eventValue),
+		tops:       make(map[int]topPage),
+		browses:    make(map[int]browse),
+		activity:   make(map[int]time.Time),
+	}
+}
+
+// Ping func  (db *Memory)
+func (db *Memory) Ping() error {
+	return nil
+}
+
+// SetLastQuote func  (db *Memory)
+func (db *Memory) SetLastQuote(chatID int, quoteID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.lastQuotes[chatID] = quoteID
+	return nil
+}
+
+// GetLastQuote func  (db *Memory)
+func (db *Memory) GetLastQuote(chatID int) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	quoteID, ok := db.lastQuotes[chatID]
+	if !ok {
+		return "", ErrEmpty
+	}
+	return quoteID, nil
+}
+
+// RemoveLastQuote func  (db *Memory)
+func (db *Memory) RemoveLastQuote(chatID int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.lastQuotes, chatID)
+	return nil
+}
+
+// TruncateLastQuotes func  (db *Memory)
+func (db *Memory) TruncateLastQuotes() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.lastQuotes = make(map[int]string)
+	return nil
+}
+
+// SetProcessor func  (db *Memory)
+func (db *Memory) SetProcessor(chatID int, processor string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.processors[chatID] = processor
+	return nil
+}
+
+// GetProcessor func  (db *Memory)
+func (db *Memory) GetProcessor(chatID int) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	processor, ok := db.processors[chatID]
+	if !ok {
+		return "", ErrEmpty
+	}
+	return processor, nil
+}
+
+// RemoveProcessor func  (db *Memory)
+func (db *Memory) RemoveProcessor(chatID int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.processors, chatID)
+	return nil
+}
+
+// TruncateProcessor func  (db *Memory)
+func (db *Memory) TruncateProcessor() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.processors = make(map[int]string)
+	return nil
+}
+
+// SetSearch func  (db *Memory)
+func (db *Memory) SetSearch(chatID int, req string, index int, quoteID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.searches[chatID] = search{req: req, index: index, quoteID: quoteID}
+	return nil
+}
+
+// GetSearch func  (db *Memory)
+func (db *Memory) GetSearch(chatID int) (string, int, string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	s, ok := db.searches[chatID]
+	if !ok {
+		return "", -1, "", ErrEmpty
+	}
+	return s.req, s.index, s.quoteID, nil
+}
+
+// TruncateSearch func  (db *Memory)
+func (db *Memory) TruncateSearch() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.searches = make(map[int]search)
+	return nil
+}
+
+// SaveQuote func  (db *Memory)
+func (db *Memory) SaveQuote(chatID int, quoteID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.saved[chatID] == nil {
+		db.saved[chatID] = make(map[string]bool)
+	}
+	db.saved[chatID][quoteID] = true
+	return nil
+}
+
+// GetSavedQuotes func  (db *Memory)
+func (db *Memory) GetSavedQuotes(chatID int) (map[string]bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	saved, ok := db.saved[chatID]
+	if !ok {
+		return nil, ErrEmpty
+	}
+
+	quotes := make(map[string]bool, len(saved))
+	for k, v := range saved {
+		quotes[k] = v
+	}
+	return quotes, nil
+}
+
+// PutQuote func  (db *Memory)
+func (db *Memory) PutQuote(quote bash.Quote) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.quotes[quote.ID] = quote
+	return nil
+}
+
+// GetQuote func  (db *Memory)
+func (db *Memory) GetQuote(quoteID string) (bash.Quote, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	quote, ok := db.quotes[quoteID]
+	if !ok {
+		return bash.Quote{}, ErrEmpty
+	}
+	return quote, nil
+}
+
+// DeleteSavedQuote func  (db *Memory)
+func (db *Memory) DeleteSavedQuote(chatID int, quoteID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.saved[chatID]; !ok {
+		return ErrEmpty
+	}
+	delete(db.saved[chatID], quoteID)
+	return nil
+}
+
+// SetVote stores user's vote for quote and reports whether it was changed
+func (db *Memory) SetVote(userID int, quoteID string, v int) (bool, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	key := vote{userID: userID, quoteID: quoteID}
+	if old, ok := db.votes[key]; ok && old == v {
+		return false, nil
+	}
+	db.votes[key] = v
+	return true, nil
+}
+
+// GetVote returns user's vote for quote
+func (db *Memory) GetVote(userID int, quoteID string) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	v, ok := db.votes[vote{userID: userID, quoteID: quoteID}]
+	if !ok {
+		return 0, ErrEmpty
+	}
+	return v, nil
+}
+
+// GetRating sums votes of bot users for quote
+func (db *Memory) GetRating(quoteID string) (int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rating := 0
+	for key, v := range db.votes {
+		if key.quoteID == quoteID {
+			rating += v
+		}
+	}
+	return rating, nil
+}
+
+// AddEvent stores user's event for quote, repeated event of the same kind
+// keeps its time unless weight is changed
+func (db *Memory) AddEvent(userID int, quoteID string, kind string, weight int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	key := event{userID, quoteID, kind}
+	value, ok := db.events[key]
+	if ok && value.weight == weight {
+		return nil
+	}
+	value.time = time.Now()
+	value.weight = weight
+	db.events[key] = value
+	return nil
+}
+
+// RemoveEvent func  (db *Memory)
+func (db *Memory) RemoveEvent(userID int, quoteID string, kind string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.events, event{userID, quoteID, kind})
+	return nil
+}
+
+// GetTop sums weights of events since given time, best quotes go first
+func (db *Memory) GetTop(since time.Time) ([]Score, error) {
+	db.mu.Lock()
+	contributions := make(contributions)
+	for key, value := range db.events {
+		if !value.time.Before(since) {
+			contributions.add(key.userID, key.quoteID, key.kind, value.weight)
+		}
+	}
+	db.mu.Unlock()
+
+	return topScores(contributions.scores()), nil
+}
+
+// SetTopPage stores top window and page shown in chat
+func (db *Memory) SetTopPage(chatID int, window string, page int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tops[chatID] = topPage{window: window, page: page}
+	return nil
+}
+
+// GetTopPage returns top window and page shown in chat
+func (db *Memory) GetTopPage(chatID int) (string, int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, ok := db.tops[chatID]
+	if !ok {
+		return "", -1, ErrEmpty
+	}
+	return t.window, t.page, nil
+}
+
+// SetBrowse stores listing, page and index of quote shown in chat
+func (db *Memory) SetBrowse(chatID int, listing string, page string, index int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.browses[chatID] = browse{listing: listing, page: page, index: index}
+	return nil
+}
+
+// GetBrowse returns listing, page and index of quote shown in chat
+func (db *Memory) GetBrowse(chatID int) (string, string, int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	b, ok := db.browses[chatID]
+	if !ok {
+		return "", "", -1, ErrEmpty
+	}
+	return b.listing, b.page, b.index, nil
+}
+
+// Touch marks session of chat as active now
+func (db *Memory) Touch(chatID int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.activity[chatID] = time.Now()
+	return nil
+}
+
+// LastActive returns time of last activity in chat, ErrEmpty if there is no session
+func (db *Memory) LastActive(chatID int) (time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	last, ok := db.activity[chatID]
+	if !ok {
+		return time.Time{}, ErrEmpty
+	}
+	return last, nil
+}
+
+// IdleSessions returns chats which were not active since given time
+func (db *Memory) IdleSessions(since time.Time) ([]int, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var chats []int
+	for chatID, last := range db.activity {
+		if last.Before(since) {
+			chats = append(chats, chatID)
+		}
+	}
+	return chats, nil
+}
+
+// RemoveSession removes state of chat, saved quotes and votes are kept
+func (db *Memory) RemoveSession(chatID int) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.processors, chatID)
+	delete(db.lastQuotes, chatID)
+	delete(db.searches, chatID)
+	delete(db.tops, chatID)
+	delete(db.browses, chatID)
+	delete(db.activity, chatID)
+	return nil
+}
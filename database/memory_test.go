@@ -0,0 +1,50 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryTop(t *testing.T) {
+	type event struct {
+		userID int
+		kind   string
+		weight int
+	}
+
+	tests := []struct {
+		name   string
+		before []event
+		after  []event
+		want   []Score
+	}{
+		{"old events are out of window", []event{{1, EventVote, 1}}, nil, []Score{}},
+		{"repeated vote keeps its time", []event{{1, EventVote, 1}}, []event{{1, EventVote, 1}}, []Score{}},
+		{"changed vote gets new time", []event{{1, EventVote, -1}}, []event{{1, EventVote, 1}}, []Score{{"q", 1}}},
+		{"save is not counted with vote", nil, []event{{1, EventSave, 1}, {1, EventVote, 1}}, []Score{{"q", 1}}},
+		{"save counts without vote", nil, []event{{1, EventSave, 1}, {2, EventSave, 1}}, []Score{{"q", 2}}},
+		{"minus vote overrides save", nil, []event{{1, EventSave, 1}, {1, EventVote, -1}, {2, EventVote, 1}}, []Score{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewMemory()
+			for _, e := range test.before {
+				db.AddEvent(e.userID, "q", e.kind, e.weight)
+			}
+			since := time.Now()
+			for _, e := range test.after {
+				db.AddEvent(e.userID, "q", e.kind, e.weight)
+			}
+
+			top, err := db.GetTop(since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(top, test.want) {
+				t.Errorf("GetTop = %v, want %v", top, test.want)
+			}
+		})
+	}
+}
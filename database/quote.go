@@ -0,0 +1,72 @@
+package database
+
+import (
+	"time"
+
+	tarantool "github.com/tarantool/go-tarantool"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// quoteFields returns fields of quote stored in tuples after its key
+func quoteFields(quote bash.Quote) []interface{} {
+	date := uint64(0)
+	if !quote.Date.IsZero() {
+		date = uint64(quote.Date.Unix())
+	}
+	return []interface{}{quote.Text, quote.Rating.String(), date, quote.Comics, quote.Section, quote.URL}
+}
+
+// fieldsQuote reads fields written by quoteFields, fields added later may be missing
+func fieldsQuote(fields []interface{}) (bash.Quote, error) {
+	if len(fields) < 3 {
+		return bash.Quote{}, ErrEmpty
+	}
+
+	text, okt := fields[0].(string)
+	rating, okr := fields[1].(string)
+	date, okd := toInt(fields[2])
+	if !okt || !okr || !okd {
+		return bash.Quote{}, ErrIncorrectType
+	}
+
+	quote := bash.Quote{Text: text, Rating: bash.ParseRating(rating)}
+	if date != 0 {
+		quote.Date = time.Unix(int64(date), 0)
+	}
+
+	optional := []*string{&quote.Comics, &quote.Section, &quote.URL}
+	for i, field := range fields[3:] {
+		if i == len(optional) {
+			break
+		}
+		s, ok := field.(string)
+		if !ok {
+			return bash.Quote{}, ErrIncorrectType
+		}
+		*optional[i] = s
+	}
+	return quote, nil
+}
+
+// PutQuote keeps quote saved by users, so saved quotes are shown without asking source
+func (db *Tarantool) PutQuote(quote bash.Quote) error {
+	tuple := append([]interface{}{quote.ID}, quoteFields(quote)...)
+	_, err := db.connection.Replace(savedQuotesDB, tuple)
+	return err
+}
+
+// GetQuote func  (db *Tarantool)
+func (db *Tarantool) GetQuote(quoteID string) (bash.Quote, error) {
+	resp, err := db.connection.Select(savedQuotesDB, primary, 0, 1, tarantool.IterEq, []interface{}{quoteID})
+	if err != nil {
+		return bash.Quote{}, err
+	}
+	if len(resp.Tuples()) == 0 {
+		return bash.Quote{}, ErrEmpty
+	}
+
+	quote, err := fieldsQuote(resp.Tuples()[0][1:])
+	quote.ID = quoteID
+	return quote, err
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"time"
+
+	tarantool "github.com/tarantool/go-tarantool"
+)
+
+// Touch marks session of chat as active now
+func (db *Tarantool) Touch(chatID int) error {
+	now := uint64(time.Now().Unix())
+	_, err := db.connection.Upsert(sessionsDB, []interface{}{chatID, now},
+		[]interface{}{[]interface{}{"=", 1, now}})
+	return err
+}
+
+// LastActive returns time of last activity in chat, ErrEmpty if there is no session
+func (db *Tarantool) LastActive(chatID int) (time.Time, error) {
+	resp, err := db.connection.Select(sessionsDB, primary, 0, 1, tarantool.IterEq, []interface{}{chatID})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 2 {
+		return time.Time{}, ErrEmpty
+	}
+
+	at, ok := toInt(resp.Tuples()[0][1])
+	if !ok {
+		return time.Time{}, ErrIncorrectType
+	}
+	return time.Unix(int64(at), 0), nil
+}
+
+// IdleSessions returns chats which were not active since given time
+func (db *Tarantool) IdleSessions(since time.Time) ([]int, error) {
+	resp, err := db.connection.Select(sessionsDB, timeIndex, 0, maxSelect, tarantool.IterLt, []interface{}{uint64(since.Unix())})
+	if err != nil {
+		return nil, err
+	}
+
+	chats := make([]int, 0, len(resp.Tuples()))
+	for _, tuple := range resp.Tuples() {
+		if len(tuple) < 2 {
+			continue
+		}
+		chatID, ok := toInt(tuple[0])
+		if !ok {
+			return nil, ErrIncorrectType
+		}
+		chats = append(chats, chatID)
+	}
+	return chats, nil
+}
+
+// RemoveSession removes state of chat, saved quotes and votes are kept
+func (db *Tarantool) RemoveSession(chatID int) error {
+	for _, name := range []string{processorsDB, quoteDB, searchDB, topDB, browseDB, sessionsDB} {
+		if err := db.deleteSpace(name, chatID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
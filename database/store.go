@@ -0,0 +1,51 @@
+package database
+
+import (
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// Store keeps state of chats, saved quotes and votes
+type Store interface {
+	Ping() error
+
+	SetLastQuote(chatID int, quoteID string) error
+	GetLastQuote(chatID int) (string, error)
+	RemoveLastQuote(chatID int) error
+	TruncateLastQuotes() error
+
+	SetProcessor(chatID int, processor string) error
+	GetProcessor(chatID int) (string, error)
+	RemoveProcessor(chatID int) error
+	TruncateProcessor() error
+
+	SetSearch(chatID int, req string, index int, quoteID string) error
+	GetSearch(chatID int) (string, int, string, error)
+	TruncateSearch() error
+
+	SaveQuote(chatID int, quoteID string) error
+	GetSavedQuotes(chatID int) (map[string]bool, error)
+	DeleteSavedQuote(chatID int, quoteID string) error
+	PutQuote(quote bash.Quote) error
+	GetQuote(quoteID string) (bash.Quote, error)
+
+	SetVote(userID int, quoteID string, vote int) (bool, error)
+	GetVote(userID int, quoteID string) (int, error)
+	GetRating(quoteID string) (int, error)
+
+	AddEvent(userID int, quoteID string, kind string, weight int) error
+	RemoveEvent(userID int, quoteID string, kind string) error
+	GetTop(since time.Time) ([]Score, error)
+
+	SetTopPage(chatID int, window string, page int) error
+	GetTopPage(chatID int) (string, int, error)
+
+	SetBrowse(chatID int, listing string, page string, index int) error
+	GetBrowse(chatID int) (string, string, int, error)
+
+	Touch(chatID int) error
+	LastActive(chatID int) (time.Time, error)
+	IdleSessions(since time.Time) ([]int, error)
+	RemoveSession(chatID int) error
+}
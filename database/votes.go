@@ -0,0 +1,91 @@
+package database
+
+import (
+	tarantool "github.com/tarantool/go-tarantool"
+)
+
+// Vote values
+const (
+	VoteMinus = -1
+	VoteBayan = 0
+	VotePlus  = 1
+)
+
+// SetVote stores user's vote for quote and reports whether it was changed
+func (db *Tarantool) SetVote(userID int, quoteID string, vote int) (bool, error) {
+	key := []interface{}{userID, quoteID}
+	resp, err := db.connection.Select(votesDB, primary, 0, 1, tarantool.IterEq, key)
+	if err != nil {
+		return false, err
+	}
+
+	if len(resp.Tuples()) != 0 && len(resp.Tuples()[0]) >= 3 {
+		old, ok := toInt(resp.Tuples()[0][2])
+		if ok && old == vote {
+			return false, nil
+		}
+	}
+
+	_, err = db.connection.Replace(votesDB, []interface{}{userID, quoteID, vote})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetVote returns user's vote for quote
+func (db *Tarantool) GetVote(userID int, quoteID string) (int, error) {
+	key := []interface{}{userID, quoteID}
+	resp, err := db.connection.Select(votesDB, primary, 0, 1, tarantool.IterEq, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp.Tuples()) == 0 || len(resp.Tuples()[0]) < 3 {
+		return 0, ErrEmpty
+	}
+
+	vote, ok := toInt(resp.Tuples()[0][2])
+	if !ok {
+		return 0, ErrIncorrectType
+	}
+	return vote, nil
+}
+
+// GetRating sums votes of bot users for quote
+func (db *Tarantool) GetRating(quoteID string) (int, error) {
+	resp, err := db.connection.Select(votesDB, quoteIndex, 0, maxSelect, tarantool.IterEq, []interface{}{quoteID})
+	if err != nil {
+		return 0, err
+	}
+
+	rating := 0
+	for _, tuple := range resp.Tuples() {
+		if len(tuple) < 3 {
+			continue
+		}
+		vote, ok := toInt(tuple[2])
+		if !ok {
+			return 0, ErrIncorrectType
+		}
+		rating += vote
+	}
+	return rating, nil
+}
+
+// TruncateVotes func  (db *Tarantool)
+func (db *Tarantool) TruncateVotes() error {
+	return db.truncateSpace(votesDB)
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case uint64:
+		return int(n), true
+	case int:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,187 @@
+package dupes
+
+import (
+	"container/list"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// Defaults of detector
+const (
+	DefaultThreshold = 0.5
+	DefaultSize      = 100000
+)
+
+// Match is a quote similar to the asked one, only id and date of quote are kept
+type Match struct {
+	Quote      bash.Quote
+	Similarity float64
+}
+
+// Detector keeps signatures of quotes and finds near-duplicates, it is
+// safe for concurrent use
+type Detector struct {
+	Threshold float64
+
+	mu      sync.RWMutex
+	size    int
+	entries map[string]*entry
+	buckets map[uint64][]string
+	// order has ids of quotes, the most recently seen first
+	order *list.List
+}
+
+type entry struct {
+	quote   bash.Quote
+	sig     Signature
+	keys    [bands]uint64
+	element *list.Element
+}
+
+// New creates empty detector which keeps up to size quotes, the least
+// recently seen ones are forgotten, zero size means no limit
+func New(threshold float64, size int) *Detector {
+	return &Detector{
+		Threshold: threshold,
+		size:      size,
+		entries:   make(map[string]*entry),
+		buckets:   make(map[uint64][]string),
+		order:     list.New(),
+	}
+}
+
+// Len returns number of known quotes
+func (d *Detector) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.entries)
+}
+
+// Add remembers quotes, quote with known id and text is only marked as seen
+func (d *Detector) Add(quotes ...bash.Quote) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, quote := range quotes {
+		if quote.ID == "" {
+			continue
+		}
+
+		sig, ok := Sign(quote.Text)
+		if old, known := d.entries[quote.ID]; known {
+			if ok && old.sig == sig {
+				d.order.MoveToFront(old.element)
+				continue
+			}
+			d.remove(old)
+		}
+		if !ok {
+			continue
+		}
+
+		// text is not needed to compare quotes
+		e := &entry{quote: bash.Quote{ID: quote.ID, Date: quote.Date}, sig: sig, keys: sig.bandKeys()}
+		e.element = d.order.PushFront(e)
+		d.entries[quote.ID] = e
+		for _, key := range e.keys {
+			d.buckets[key] = append(d.buckets[key], quote.ID)
+		}
+
+		for d.size > 0 && d.order.Len() > d.size {
+			d.remove(d.order.Back().Value.(*entry))
+		}
+	}
+}
+
+func (d *Detector) remove(e *entry) {
+	d.order.Remove(e.element)
+	delete(d.entries, e.quote.ID)
+	for _, key := range e.keys {
+		ids := d.buckets[key]
+		for i, id := range ids {
+			if id == e.quote.ID {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(d.buckets, key)
+		} else {
+			d.buckets[key] = ids
+		}
+	}
+}
+
+// Similar returns known quotes similar to quote with id, most similar first
+func (d *Detector) Similar(id string) ([]Match, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.similar(id)
+}
+
+func (d *Detector) similar(id string) ([]Match, bool) {
+	e, ok := d.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	seen := map[string]bool{id: true}
+	var matches []Match
+	for _, key := range e.keys {
+		for _, other := range d.buckets[key] {
+			if seen[other] {
+				continue
+			}
+			seen[other] = true
+
+			candidate := d.entries[other]
+			if similarity := e.sig.Similarity(&candidate.sig); similarity >= d.Threshold {
+				matches = append(matches, Match{Quote: candidate.quote, Similarity: similarity})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return older(matches[i].Quote, matches[j].Quote)
+	})
+	return matches, true
+}
+
+// Original returns the oldest known quote which quote with id repeats
+func (d *Detector) Original(id string) (bash.Quote, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	matches, ok := d.similar(id)
+	if !ok || len(matches) == 0 {
+		return bash.Quote{}, false
+	}
+	quote := d.entries[id].quote
+
+	original, found := quote, false
+	for _, match := range matches {
+		if older(match.Quote, original) {
+			original, found = match.Quote, true
+		}
+	}
+	return original, found
+}
+
+// older compares quotes by date if both are dated and by number otherwise
+func older(a, b bash.Quote) bool {
+	if !a.Date.IsZero() && !b.Date.IsZero() && !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	na, errA := strconv.Atoi(a.ID)
+	nb, errB := strconv.Atoi(b.ID)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a.ID < b.ID
+}
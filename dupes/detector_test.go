@@ -0,0 +1,80 @@
+package dupes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+var (
+	fridge    = "xxx: я вчера купил холодильник, а он оказался без дверцы\nyyy: зато свежо"
+	repost    = "<xxx> Я вчера купил холодильник а он оказался без дверцы!\n<yyy> зато свежо 12:30"
+	cats      = "совсем другая цитата про котиков и программистов"
+	yesterday = time.Now().Add(-24 * time.Hour)
+)
+
+func TestOriginal(t *testing.T) {
+	tests := []struct {
+		name     string
+		quotes   []bash.Quote
+		id       string
+		original string
+	}{
+		{"older number", []bash.Quote{{ID: "205", Text: repost}, {ID: "100", Text: fridge}}, "205", "100"},
+		{"original has no original", []bash.Quote{{ID: "205", Text: repost}, {ID: "100", Text: fridge}}, "100", ""},
+		{"older date", []bash.Quote{{ID: "100", Text: fridge, Date: time.Now()}, {ID: "205", Text: repost, Date: yesterday}}, "100", "205"},
+		{"abyss quote repeats numbered", []bash.Quote{{ID: "100", Text: fridge}, {ID: "AB12CD", Text: repost}}, "AB12CD", "100"},
+		{"different quote", []bash.Quote{{ID: "100", Text: fridge}, {ID: "300", Text: cats}}, "300", ""},
+		{"unknown quote", []bash.Quote{{ID: "100", Text: fridge}}, "205", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := New(DefaultThreshold, 0)
+			d.Add(test.quotes...)
+
+			original, ok := d.Original(test.id)
+			if ok != (test.original != "") || ok && original.ID != test.original {
+				t.Errorf("Original(%s) = %q, %v, want %q", test.id, original.ID, ok, test.original)
+			}
+		})
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	d := New(DefaultThreshold, 0)
+	d.Add(bash.Quote{ID: "100", Text: fridge}, bash.Quote{ID: "205", Text: repost}, bash.Quote{ID: "300", Text: cats})
+
+	matches, ok := d.Similar("100")
+	if !ok || len(matches) != 1 || matches[0].Quote.ID != "205" {
+		t.Errorf("Similar = %v, %v, want quote 205", matches, ok)
+	}
+
+	// changed text replaces signature
+	d.Add(bash.Quote{ID: "205", Text: cats})
+	if matches, _ := d.Similar("100"); len(matches) != 0 {
+		t.Errorf("old text of 205 is still matched: %v", matches)
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	d := New(DefaultThreshold, 2)
+	d.Add(bash.Quote{ID: "100", Text: fridge}, bash.Quote{ID: "300", Text: cats})
+	// seen again, so 300 is the least recently seen one
+	d.Add(bash.Quote{ID: "100", Text: fridge})
+	d.Add(bash.Quote{ID: "205", Text: repost})
+
+	if d.Len() != 2 {
+		t.Errorf("Len = %d, want 2", d.Len())
+	}
+	if _, ok := d.Similar("300"); ok {
+		t.Error("the least recently seen quote is kept")
+	}
+	if original, ok := d.Original("205"); !ok || original.ID != "100" {
+		t.Errorf("Original = %q, %v, want 100", original.ID, ok)
+	}
+	if len(d.buckets) > 2*bands {
+		t.Errorf("buckets of forgotten quote are kept: %d", len(d.buckets))
+	}
+}
@@ -0,0 +1,121 @@
+// Package dupes finds near-duplicate quotes by MinHash signatures of word shingles
+package dupes
+
+import (
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// Signature parameters, signature of numHashes values is split to bands
+// of rows values for locality-sensitive hashing
+const (
+	shingleSize = 3
+	numHashes   = 128
+	bands       = 32
+	rows        = numHashes / bands
+)
+
+// Signature is a MinHash signature of quote text
+type Signature [numHashes]uint32
+
+var seeds = func() [numHashes]uint64 {
+	var result [numHashes]uint64
+	state := uint64(0x5ba5)
+	for i := range result {
+		state += 0x9e3779b97f4a7c15
+		result[i] = mix(state)
+	}
+	return result
+}()
+
+// mix is a finalizer of splitmix64
+func mix(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// normalize returns lower-cased words of text, numbers are dropped as
+// reposts often change times and dates in quotes
+func normalize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	result := words[:0]
+	for _, word := range words {
+		if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		result = append(result, strings.Replace(word, "ё", "е", -1))
+	}
+	return result
+}
+
+// shingles returns hashes of every shingleSize words in a row, short text
+// is a single shingle
+func shingles(words []string) []uint64 {
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) < shingleSize {
+		return []uint64{hashString(strings.Join(words, " "))}
+	}
+
+	result := make([]uint64, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		result = append(result, hashString(strings.Join(words[i:i+shingleSize], " ")))
+	}
+	return result
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Sign computes signature of text, ok is false if text has no words
+func Sign(text string) (sig Signature, ok bool) {
+	hashes := shingles(normalize(text))
+	if len(hashes) == 0 {
+		return sig, false
+	}
+
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	for _, h := range hashes {
+		for i, seed := range seeds {
+			if v := uint32(mix(h^seed) >> 32); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig, true
+}
+
+// Similarity estimates Jaccard similarity of shingles of two texts
+func (sig *Signature) Similarity(other *Signature) float64 {
+	same := 0
+	for i := range sig {
+		if sig[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / numHashes
+}
+
+// bandKeys returns hash of every band, equal keys make quotes candidates
+func (sig *Signature) bandKeys() [bands]uint64 {
+	var keys [bands]uint64
+	for band := range keys {
+		h := seeds[band]
+		for _, v := range sig[band*rows : (band+1)*rows] {
+			h = mix(h ^ uint64(v))
+		}
+		keys[band] = h
+	}
+	return keys
+}
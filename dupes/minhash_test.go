@@ -0,0 +1,44 @@
+package dupes
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	const original = "xxx: я вчера купил холодильник, а он оказался без дверцы\nyyy: зато свежо"
+
+	tests := []struct {
+		name string
+		text string
+		min  float64
+		max  float64
+	}{
+		{"same text", original, 1, 1},
+		{"case, punctuation and nicks", "<XXX> Я вчера купил холодильник а он оказался без дверцы!!!\n<yyy> Зато свежо", 0.9, 1},
+		{"times and dates are dropped", original + " 12:30 01.02.2003", 0.9, 1},
+		{"ё is е", "xxx: я вчера купил холодильник, а он оказался без дверцы\nyyy: зато свёжо", 1, 1},
+		{"one word changed", "xxx: я вчера купил телевизор, а он оказался без дверцы\nyyy: зато свежо", 0.3, 0.8},
+		{"other quote", "совсем другая цитата про котиков и программистов", 0, 0.1},
+	}
+
+	sig, ok := Sign(original)
+	if !ok {
+		t.Fatal("original has no signature")
+	}
+	for _, test := range tests {
+		other, ok := Sign(test.text)
+		if !ok {
+			t.Errorf("%s: no signature", test.name)
+			continue
+		}
+		if similarity := sig.Similarity(&other); similarity < test.min || similarity > test.max {
+			t.Errorf("%s: similarity %.2f, want [%.2f, %.2f]", test.name, similarity, test.min, test.max)
+		}
+	}
+}
+
+func TestSignWithoutWords(t *testing.T) {
+	for _, text := range []string{"", "  ", "12:30 01.02.2003", "!!! ..."} {
+		if _, ok := Sign(text); ok {
+			t.Errorf("Sign(%q) has signature", text)
+		}
+	}
+}
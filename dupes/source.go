@@ -0,0 +1,56 @@
+package dupes
+
+import (
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// Source remembers signature of every quote of source
+type Source struct {
+	bash.QuoteSource
+	Detector *Detector
+}
+
+// NewSource wraps source with detector
+func NewSource(source bash.QuoteSource, detector *Detector) *Source {
+	return &Source{QuoteSource: source, Detector: detector}
+}
+
+// Unwrap returns wrapped source
+func (s *Source) Unwrap() bash.QuoteSource {
+	return s.QuoteSource
+}
+
+// Random returns random quotes of source
+func (s *Source) Random() ([]bash.Quote, error) {
+	quotes, err := s.QuoteSource.Random()
+	s.Detector.Add(quotes...)
+	return quotes, err
+}
+
+// Search returns quotes of source matching query
+func (s *Source) Search(query string) ([]bash.Quote, error) {
+	quotes, err := s.QuoteSource.Search(query)
+	s.Detector.Add(quotes...)
+	return quotes, err
+}
+
+// ByID returns quote of source
+func (s *Source) ByID(id string) (bash.Quote, error) {
+	quote, err := s.QuoteSource.ByID(id)
+	if err == nil {
+		s.Detector.Add(quote)
+	}
+	return quote, err
+}
+
+// Browse returns page of source listing
+func (s *Source) Browse(listing string, token string) (bash.Page, error) {
+	browser, ok := s.QuoteSource.(bash.Browser)
+	if !ok {
+		return bash.Page{}, bash.ErrUnsupported
+	}
+
+	page, err := browser.Browse(listing, token)
+	s.Detector.Add(page.Quotes...)
+	return page, err
+}
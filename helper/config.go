@@ -0,0 +1,166 @@
+package helper
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadConfig fills config from yaml file and then from environment variables
+// named as envPrefix followed by upper-cased yaml key. Missing file is an error
+// only when required is set.
+func LoadConfig(path string, required bool, envPrefix string, config interface{}) error {
+	if _, err := os.Stat(path); err == nil || required {
+		if err := GetYamlConfig(path, config); err != nil {
+			return err
+		}
+	}
+
+	return eachField(config, func(name string, field reflect.Value) error {
+		env := envPrefix + strings.ToUpper(name)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return nil
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid environment variable %s: %s", env, err)
+		}
+		return nil
+	})
+}
+
+// Flags overrides config fields by command line flags
+type Flags struct {
+	prefix string
+	values map[string]*string
+	fs     *flag.FlagSet
+}
+
+// NewFlags defines flag named as prefix followed by yaml key for every
+// field of config, underscores are replaced by dashes
+func NewFlags(fs *flag.FlagSet, prefix string, config interface{}) *Flags {
+	flags := &Flags{
+		prefix: prefix,
+		values: make(map[string]*string),
+		fs:     fs,
+	}
+
+	eachField(config, func(name string, field reflect.Value) error {
+		flags.values[name] = fs.String(flagName(prefix, name), "", fmt.Sprintf("overrides %q from config", name))
+		return nil
+	})
+
+	return flags
+}
+
+// Apply sets fields of config for flags which were given, must be called after parsing
+func (flags *Flags) Apply(config interface{}) error {
+	if flags == nil {
+		return nil
+	}
+
+	given := make(map[string]bool)
+	flags.fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	return eachField(config, func(name string, field reflect.Value) error {
+		value, ok := flags.values[name]
+		if !ok || !given[flagName(flags.prefix, name)] {
+			return nil
+		}
+		if err := setField(field, *value); err != nil {
+			return fmt.Errorf("invalid flag --%s: %s", flagName(flags.prefix, name), err)
+		}
+		return nil
+	})
+}
+
+func flagName(prefix, name string) string {
+	return prefix + strings.Replace(name, "_", "-", -1)
+}
+
+// eachField calls f for every field of struct pointed by config which has yaml tag
+func eachField(config interface{}, f func(name string, field reflect.Value) error) error {
+	v := reflect.ValueOf(config).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		// nested sections are configured by file only
+		if kind := v.Field(i).Kind(); kind == reflect.Struct || kind == reflect.Map {
+			continue
+		}
+		if err := f(name, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Slice:
+		items := strings.Split(value, ",")
+		slice := reflect.MakeSlice(field.Type(), 0, len(items))
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(elem, item); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		field.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
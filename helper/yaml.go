@@ -7,16 +7,41 @@ import (
 	yaml "gopkg.in/yaml.v2"
 )
 
-// GetYamlConfig parse .yaml
+// GetYamlConfig parses .yaml, unknown keys are errors and keys left empty
+// keep values config already has
 func GetYamlConfig(path string, config interface{}) error {
 	configContent, err := ioutil.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("can't read config %q: %s", path, err)
 	}
 
-	if err = yaml.Unmarshal(configContent, config); err != nil {
+	var content yaml.MapSlice
+	if err = yaml.Unmarshal(configContent, &content); err != nil {
+		return fmt.Errorf("invalid yaml in config %q: %s", path, err)
+	}
+	configContent, err = yaml.Marshal(withoutEmpty(content))
+	if err != nil {
+		return fmt.Errorf("invalid yaml in config %q: %s", path, err)
+	}
+
+	if err = yaml.UnmarshalStrict(configContent, config); err != nil {
 		return fmt.Errorf("invalid yaml in config %q: %s", path, err)
 	}
 
 	return nil
 }
+
+// withoutEmpty drops keys without value, nested sections included
+func withoutEmpty(content yaml.MapSlice) yaml.MapSlice {
+	result := make(yaml.MapSlice, 0, len(content))
+	for _, item := range content {
+		switch value := item.Value.(type) {
+		case nil:
+			continue
+		case yaml.MapSlice:
+			item.Value = withoutEmpty(value)
+		}
+		result = append(result, item)
+	}
+	return result
+}
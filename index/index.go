@@ -0,0 +1,324 @@
+// Package index is a full-text index of quotes with BM25 ranking
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Index is an inverted index of quotes, it is safe for concurrent use
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[string]*document
+	postings map[string]map[string][]int
+	totalLen int
+
+	// log keeps every added quote, it is nil if index is not persisted
+	log *os.File
+}
+
+type document struct {
+	quote  bash.Quote
+	length int
+	terms  []string
+}
+
+// New creates empty index kept in memory only
+func New() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string][]int),
+	}
+}
+
+// Open loads index from file at path and appends every new quote to it.
+// File has one JSON quote per line, later lines replace earlier ones.
+func Open(path string) (*Index, error) {
+	idx := New()
+
+	lines := 0
+	broken := false
+	file, err := os.Open(path)
+	if err == nil {
+		decoder := json.NewDecoder(bufio.NewReader(file))
+		for {
+			var quote bash.Quote
+			err := decoder.Decode(&quote)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// tail written during crash is dropped by compaction
+				broken = true
+				break
+			}
+			idx.add(quote)
+			lines++
+		}
+		file.Close()
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("can't open index: %s", err)
+	}
+
+	if broken || lines > 2*len(idx.docs) {
+		if err := idx.compact(path); err != nil {
+			return nil, fmt.Errorf("can't compact index: %s", err)
+		}
+	}
+
+	idx.log, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open index: %s", err)
+	}
+	return idx, nil
+}
+
+// compact rewrites file with current quotes only
+func (idx *Index) compact(path string) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, doc := range idx.docs {
+		if err := encoder.Encode(doc.quote); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Close closes file of index
+func (idx *Index) Close() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.log == nil {
+		return nil
+	}
+	err := idx.log.Close()
+	idx.log = nil
+	return err
+}
+
+// Len returns number of indexed quotes
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Each calls f for every indexed quote until f returns false
+func (idx *Index) Each(f func(quote bash.Quote) bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	for _, doc := range idx.docs {
+		if !f(doc.quote) {
+			return
+		}
+	}
+}
+
+// Add indexes quotes, quote with known id replaces the old one
+func (idx *Index) Add(quotes ...bash.Quote) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, quote := range quotes {
+		if !idx.add(quote) || idx.log == nil {
+			continue
+		}
+		data, err := json.Marshal(quote)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.log.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("can't write index: %s", err)
+		}
+	}
+	return nil
+}
+
+// add indexes quote and reports whether index was changed
+func (idx *Index) add(quote bash.Quote) bool {
+	quote.LocalRating = 0
+	if quote.ID == "" || quote.Text == "" {
+		return false
+	}
+
+	old, ok := idx.docs[quote.ID]
+	if ok && old.quote == quote {
+		return false
+	}
+	if ok && old.quote.Text == quote.Text {
+		old.quote = quote
+		return true
+	}
+	if ok {
+		idx.remove(old)
+	}
+
+	doc := &document{quote: quote}
+	for i, term := range terms(quote.Text) {
+		positions := idx.postings[term]
+		if positions == nil {
+			positions = make(map[string][]int)
+			idx.postings[term] = positions
+		}
+		if positions[quote.ID] == nil {
+			doc.terms = append(doc.terms, term)
+		}
+		positions[quote.ID] = append(positions[quote.ID], i)
+		doc.length++
+	}
+
+	idx.docs[quote.ID] = doc
+	idx.totalLen += doc.length
+	return true
+}
+
+func (idx *Index) remove(doc *document) {
+	for _, term := range doc.terms {
+		delete(idx.postings[term], doc.quote.ID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docs, doc.quote.ID)
+	idx.totalLen -= doc.length
+}
+
+// Search returns up to limit quotes matching query, best go first. Query
+// words must all be found, "quoted phrases" must be found as is and words
+// or phrases starting with minus must not be found.
+func (idx *Index) Search(text string, limit int) []bash.Quote {
+	q := parseQuery(text)
+	if len(q.phrases) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type result struct {
+		doc   *document
+		score float64
+	}
+	var results []result
+
+	for id := range idx.candidates(q.phrases) {
+		doc := idx.docs[id]
+		if !idx.matches(id, q) {
+			continue
+		}
+		results = append(results, result{doc, idx.score(doc, q.phrases)})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].doc.quote.ID < results[j].doc.quote.ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	quotes := make([]bash.Quote, len(results))
+	for i, r := range results {
+		quotes[i] = r.doc.quote
+	}
+	return quotes
+}
+
+// candidates returns ids of quotes having the rarest term of phrases
+func (idx *Index) candidates(phrases [][]string) map[string][]int {
+	rarest := idx.postings[phrases[0][0]]
+	for _, phrase := range phrases {
+		for _, term := range phrase {
+			if positions := idx.postings[term]; len(positions) < len(rarest) {
+				rarest = positions
+			}
+		}
+	}
+	return rarest
+}
+
+func (idx *Index) matches(id string, q query) bool {
+	for _, phrase := range q.phrases {
+		if !idx.hasPhrase(id, phrase) {
+			return false
+		}
+	}
+	for _, phrase := range q.excluded {
+		if idx.hasPhrase(id, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPhrase reports whether terms of phrase follow each other in quote
+func (idx *Index) hasPhrase(id string, phrase []string) bool {
+	first := idx.postings[phrase[0]][id]
+	for _, start := range first {
+		found := true
+		for k, term := range phrase[1:] {
+			if !containsInt(idx.postings[term][id], start+k+1) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(sorted []int, n int) bool {
+	i := sort.SearchInts(sorted, n)
+	return i < len(sorted) && sorted[i] == n
+}
+
+// score is BM25 of quote for terms of phrases
+func (idx *Index) score(doc *document, phrases [][]string) float64 {
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+
+	score := 0.0
+	for _, phrase := range phrases {
+		for _, term := range phrase {
+			positions := idx.postings[term]
+			df := float64(len(positions))
+			tf := float64(len(positions[doc.quote.ID]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(doc.length)/avgLen))
+		}
+	}
+	return score
+}
@@ -0,0 +1,122 @@
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+var quotes = []bash.Quote{
+	{ID: "1", Text: "xxx: купил холодильник\nyyy: а дверца где?"},
+	{ID: "2", Text: "котики и программисты, программисты и котики, котики"},
+	{ID: "3", Text: "злой кот съел программиста"},
+	{ID: "4", Text: "кот злой, а программист добрый"},
+	{ID: "5", Text: "длинная цитата про котов, в которой кот встречается один раз среди многих других слов"},
+}
+
+func ids(quotes []bash.Quote) []string {
+	result := make([]string, len(quotes))
+	for i, quote := range quotes {
+		result[i] = quote.ID
+	}
+	return result
+}
+
+func TestSearch(t *testing.T) {
+	idx := New()
+	if err := idx.Add(quotes...); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		{"холодильники", 0, []string{"1"}},
+		{"ХОЛОДИЛЬНИКА дверцу", 0, []string{"1"}},
+		{"холодильник котики", 0, nil},
+		// quote with more mentions goes first, shorter one wins a tie
+		{"кот", 0, []string{"3", "4", "5"}},
+		{"котики", 0, []string{"2"}},
+		{`"злой кот"`, 0, []string{"3"}},
+		{`кот -"злой кот"`, 0, []string{"4", "5"}},
+		{"программисты -котики", 0, []string{"3", "4"}},
+		{"кот", 2, []string{"3", "4"}},
+		{"-кот", 0, nil},
+		{"", 0, nil},
+	}
+
+	for _, test := range tests {
+		got := ids(idx.Search(test.query, test.limit))
+		if len(got) != len(test.want) {
+			t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAddReplacesQuote(t *testing.T) {
+	idx := New()
+	idx.Add(bash.Quote{ID: "1", Text: "старый текст про котов"})
+	idx.Add(bash.Quote{ID: "1", Text: "новый текст про собак"}, bash.Quote{ID: "2"})
+
+	if idx.Len() != 1 {
+		t.Errorf("Len = %d, want 1", idx.Len())
+	}
+	if got := idx.Search("кот", 0); len(got) != 0 {
+		t.Errorf("old text is still found: %v", got)
+	}
+	if got := idx.Search("собаки", 0); len(got) != 1 {
+		t.Errorf("new text is not found")
+	}
+}
+
+func TestOpenPersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "index.jsonl")
+
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Add(quotes...)
+	idx.Add(bash.Quote{ID: "1", Text: "холодильник без дверцы"})
+	idx.Close()
+
+	// tail of file written during crash
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"id": "6", "te`)
+	file.Close()
+
+	idx, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	if idx.Len() != len(quotes) {
+		t.Errorf("Len = %d, want %d", idx.Len(), len(quotes))
+	}
+	if got := idx.Search("купил", 0); len(got) != 0 {
+		t.Errorf("replaced text is found: %v", got)
+	}
+	if got := idx.Search("дверцы", 0); len(got) != 1 || got[0].ID != "1" {
+		t.Errorf("Search = %v, want quote 1", got)
+	}
+}
@@ -0,0 +1,59 @@
+package index
+
+import (
+	"strings"
+)
+
+// query is a parsed search query
+type query struct {
+	// phrases must be found, a single word is a phrase of one term
+	phrases [][]string
+	// excluded phrases must not be found
+	excluded [][]string
+}
+
+// parseQuery parses words, "quoted phrases" and -exclusions
+func parseQuery(text string) query {
+	var q query
+
+	for text != "" {
+		text = strings.TrimLeft(text, " \t\n")
+		if text == "" {
+			break
+		}
+
+		exclude := false
+		if text[0] == '-' {
+			exclude = true
+			text = text[1:]
+		}
+
+		var part string
+		if strings.HasPrefix(text, "\"") {
+			end := strings.Index(text[1:], "\"")
+			if end < 0 {
+				part, text = text[1:], ""
+			} else {
+				part, text = text[1:end+1], text[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(text, " \t\n")
+			if end < 0 {
+				part, text = text, ""
+			} else {
+				part, text = text[:end], text[end:]
+			}
+		}
+
+		phrase := terms(part)
+		if len(phrase) == 0 {
+			continue
+		}
+		if exclude {
+			q.excluded = append(q.excluded, phrase)
+		} else {
+			q.phrases = append(q.phrases, phrase)
+		}
+	}
+	return q
+}
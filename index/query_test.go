@@ -0,0 +1,28 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want query
+	}{
+		{"", query{}},
+		{"  котики ", query{phrases: [][]string{{"котик"}}}},
+		{"злой кот", query{phrases: [][]string{{"злой"}, {"кот"}}}},
+		{`"злой кот"`, query{phrases: [][]string{{"злой", "кот"}}}},
+		{`кот -собаки`, query{phrases: [][]string{{"кот"}}, excluded: [][]string{{"собак"}}}},
+		{`кот -"злой пёс"`, query{phrases: [][]string{{"кот"}}, excluded: [][]string{{"злой", "пес"}}}},
+		{`"unterminated phrase`, query{phrases: [][]string{{"untermin", "phrase"}}}},
+		{`- -- "" кот`, query{phrases: [][]string{{"кот"}}}},
+	}
+
+	for _, test := range tests {
+		if got := parseQuery(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseQuery(%q) = %#v, want %#v", test.text, got, test.want)
+		}
+	}
+}
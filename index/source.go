@@ -0,0 +1,106 @@
+package index
+
+import (
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
+)
+
+// Source indexes every quote of source and searches index before asking source
+type Source struct {
+	bash.QuoteSource
+	Index *Index
+	// MinResults is a number of indexed quotes enough to answer without
+	// asking source, zero means source is never asked
+	MinResults int
+	// MaxResults limits number of quotes returned by Search
+	MaxResults int
+}
+
+// NewSource wraps source with index
+func NewSource(source bash.QuoteSource, idx *Index, minResults, maxResults int) *Source {
+	return &Source{QuoteSource: source, Index: idx, MinResults: minResults, MaxResults: maxResults}
+}
+
+// Unwrap returns wrapped source
+func (s *Source) Unwrap() bash.QuoteSource {
+	return s.QuoteSource
+}
+
+// Random returns random quotes of source
+func (s *Source) Random() ([]bash.Quote, error) {
+	quotes, err := s.QuoteSource.Random()
+	s.add(quotes...)
+	return quotes, err
+}
+
+// Search returns indexed quotes matching query. If there are fewer than
+// MinResults of them, quotes found by source which are not indexed yet follow.
+func (s *Source) Search(query string) ([]bash.Quote, error) {
+	local := s.Index.Search(query, s.MaxResults)
+	if len(local) >= s.MinResults || !s.QuoteSource.Capabilities().Has(bash.CanSearch) {
+		return local, nil
+	}
+
+	remote, err := s.QuoteSource.Search(query)
+	s.add(remote...)
+	if err != nil {
+		if len(local) == 0 {
+			return nil, err
+		}
+		logging.Warnf("can't search source, only indexed quotes are returned: %s", err)
+	}
+	return s.merge(local, remote), nil
+}
+
+// merge appends quotes of remote missing in local, result is limited by MaxResults
+func (s *Source) merge(local []bash.Quote, remote []bash.Quote) []bash.Quote {
+	seen := make(map[string]bool, len(local))
+	for _, quote := range local {
+		seen[quote.ID] = true
+	}
+
+	quotes := local
+	for _, quote := range remote {
+		if s.MaxResults > 0 && len(quotes) >= s.MaxResults {
+			break
+		}
+		if quote.ID != "" && seen[quote.ID] {
+			continue
+		}
+		seen[quote.ID] = true
+		quotes = append(quotes, quote)
+	}
+	return quotes
+}
+
+// ByID returns quote of source
+func (s *Source) ByID(id string) (bash.Quote, error) {
+	quote, err := s.QuoteSource.ByID(id)
+	if err == nil {
+		s.add(quote)
+	}
+	return quote, err
+}
+
+// Browse returns page of source listing
+func (s *Source) Browse(listing string, token string) (bash.Page, error) {
+	browser, ok := s.QuoteSource.(bash.Browser)
+	if !ok {
+		return bash.Page{}, bash.ErrUnsupported
+	}
+
+	page, err := browser.Browse(listing, token)
+	s.add(page.Quotes...)
+	return page, err
+}
+
+// Capabilities of source, searching is always possible
+func (s *Source) Capabilities() bash.Capabilities {
+	return s.QuoteSource.Capabilities() | bash.CanSearch
+}
+
+func (s *Source) add(quotes ...bash.Quote) {
+	if err := s.Index.Add(quotes...); err != nil {
+		logging.Errorf("can't index quotes: %s", err)
+	}
+}
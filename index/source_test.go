@@ -0,0 +1,93 @@
+package index
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/bash"
+)
+
+// siteSource answers every search with the same quotes
+type siteSource struct {
+	*bash.Static
+	found    []bash.Quote
+	err      error
+	searches int
+}
+
+func (s *siteSource) Search(query string) ([]bash.Quote, error) {
+	s.searches++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.found, nil
+}
+
+func TestSourceSearch(t *testing.T) {
+	site := []bash.Quote{
+		{ID: "3", Text: "злой кот съел программиста"},
+		{ID: "10", Text: "кот с сайта"},
+		{ID: "11", Text: "еще кот с сайта"},
+	}
+
+	tests := []struct {
+		name       string
+		minResults int
+		maxResults int
+		err        error
+		want       []string
+		searches   int
+	}{
+		{"enough indexed quotes", 3, 10, nil, []string{"3", "4", "5"}, 0},
+		{"site adds missing quotes", 4, 10, nil, []string{"3", "4", "5", "10", "11"}, 1},
+		{"merged quotes are limited", 4, 4, nil, []string{"3", "4", "5", "10"}, 1},
+		{"site is never asked", 0, 10, nil, []string{"3", "4", "5"}, 0},
+		{"failed site", 4, 10, errors.New("down"), []string{"3", "4", "5"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			remote := &siteSource{Static: bash.NewStatic(nil), found: site, err: test.err}
+			idx := New()
+			idx.Add(quotes...)
+			s := NewSource(remote, idx, test.minResults, test.maxResults)
+
+			found, err := s.Search("кот")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(found)
+			if len(got) != len(test.want) {
+				t.Fatalf("Search = %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("Search = %q, want %q", got, test.want)
+				}
+			}
+			if remote.searches != test.searches {
+				t.Errorf("site is searched %d times, want %d", remote.searches, test.searches)
+			}
+		})
+	}
+}
+
+func TestSourceSearchFailsWithoutIndexedQuotes(t *testing.T) {
+	remote := &siteSource{Static: bash.NewStatic(nil), err: errors.New("down")}
+	s := NewSource(remote, New(), 1, 10)
+	if _, err := s.Search("кот"); err == nil {
+		t.Error("error of site is lost")
+	}
+}
+
+func TestSourceIndexesSeenQuotes(t *testing.T) {
+	remote := &siteSource{Static: bash.NewStatic(quotes)}
+	s := NewSource(remote, New(), 1, 10)
+
+	if _, err := s.Random(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Index.Len() != len(quotes) {
+		t.Errorf("indexed %d quotes, want %d", s.Index.Len(), len(quotes))
+	}
+}
@@ -0,0 +1,178 @@
+package index
+
+import "strings"
+
+// English stemmer follows Porter algorithm
+// https://tartarus.org/martin/PorterStemmer/def.txt
+
+// isConsonant reports whether i-th letter of w is a consonant
+func isConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !isConsonant(w, i-1)
+	}
+	return true
+}
+
+// measure counts VC sequences of w
+func measure(w string) int {
+	m := 0
+	i := 0
+	for i < len(w) && isConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !isConsonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		m++
+		for i < len(w) && isConsonant(w, i) {
+			i++
+		}
+	}
+	return m
+}
+
+func hasVowel(w string) bool {
+	for i := range w {
+		if !isConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsDoubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && isConsonant(w, n-1)
+}
+
+// endsCVC reports whether w ends with consonant, vowel and consonant which is not w, x or y
+func endsCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !isConsonant(w, n-3) || isConsonant(w, n-2) || !isConsonant(w, n-1) {
+		return false
+	}
+	c := w[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+type enRule struct {
+	suffix      string
+	replacement string
+}
+
+var enStep2 = []enRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"}, {"izer", "ize"},
+	{"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"}, {"ousli", "ous"},
+	{"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"},
+	{"fulness", "ful"}, {"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+var enStep3 = []enRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"}, {"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var enStep4 = []string{
+	"ement", "ment", "ance", "ence", "able", "ible", "ant", "ent", "ism", "ate", "iti", "ous", "ive", "ize",
+	"ion", "al", "er", "ic", "ou",
+}
+
+// applyRules replaces the longest matching suffix if stem has measure above min
+func applyRules(w string, rules []enRule, min int) string {
+	best := -1
+	for i, rule := range rules {
+		if strings.HasSuffix(w, rule.suffix) && (best < 0 || len(rule.suffix) > len(rules[best].suffix)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return w
+	}
+	stem := strings.TrimSuffix(w, rules[best].suffix)
+	if measure(stem) > min {
+		return stem + rules[best].replacement
+	}
+	return w
+}
+
+func stemEnglish(w string) string {
+	if len(w) <= 2 {
+		return w
+	}
+
+	// step 1a
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+	case strings.HasSuffix(w, "s"):
+		w = w[:len(w)-1]
+	}
+
+	// step 1b
+	if strings.HasSuffix(w, "eed") {
+		if measure(w[:len(w)-3]) > 0 {
+			w = w[:len(w)-1]
+		}
+	} else {
+		stem := ""
+		cut := false
+		if strings.HasSuffix(w, "ed") && hasVowel(w[:len(w)-2]) {
+			stem, cut = w[:len(w)-2], true
+		} else if strings.HasSuffix(w, "ing") && hasVowel(w[:len(w)-3]) {
+			stem, cut = w[:len(w)-3], true
+		}
+		if cut {
+			w = stem
+			switch {
+			case strings.HasSuffix(w, "at"), strings.HasSuffix(w, "bl"), strings.HasSuffix(w, "iz"):
+				w += "e"
+			case endsDoubleConsonant(w) && !strings.ContainsAny(w[len(w)-1:], "lsz"):
+				w = w[:len(w)-1]
+			case measure(w) == 1 && endsCVC(w):
+				w += "e"
+			}
+		}
+	}
+
+	// step 1c
+	if strings.HasSuffix(w, "y") && hasVowel(w[:len(w)-1]) {
+		w = w[:len(w)-1] + "i"
+	}
+
+	w = applyRules(w, enStep2, 0)
+	w = applyRules(w, enStep3, 0)
+
+	// step 4
+	for _, suffix := range enStep4 {
+		if !strings.HasSuffix(w, suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(w, suffix)
+		if measure(stem) > 1 && (suffix != "ion" || strings.HasSuffix(stem, "s") || strings.HasSuffix(stem, "t")) {
+			w = stem
+		}
+		break
+	}
+
+	// step 5
+	if strings.HasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := measure(stem); m > 1 || m == 1 && !endsCVC(stem) {
+			w = stem
+		}
+	}
+	if measure(w) > 1 && endsDoubleConsonant(w) && strings.HasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -0,0 +1,173 @@
+package index
+
+// Russian stemmer follows snowball algorithm
+// http://snowball.tartarus.org/algorithms/russian/stemmer.html
+
+var (
+	ruPerfectiveGerund1 = []string{"в", "вши", "вшись"}
+	ruPerfectiveGerund2 = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	ruAdjective         = []string{"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею"}
+	ruParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	ruParticiple2 = []string{"ивш", "ывш", "ующ"}
+	ruReflexive   = []string{"ся", "сь"}
+	ruVerb1       = []string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"}
+	ruVerb2       = []string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"}
+	ruNoun = []string{"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я"}
+	ruSuperlative     = []string{"ейш", "ейше"}
+	ruDerivational    = []string{"ост", "ость"}
+	ruVowels          = "аеиоуыэюя"
+	ruGroup1Preceding = "ая"
+)
+
+func isRuVowel(r rune) bool {
+	for _, v := range ruVowels {
+		if r == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ruRegions returns starts of RV and R2 regions of word
+func ruRegions(word []rune) (rv, r2 int) {
+	rv, r2 = len(word), len(word)
+	for i, r := range word {
+		if isRuVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+
+	r1 := len(word)
+	for i := 1; i < len(word); i++ {
+		if !isRuVowel(word[i]) && isRuVowel(word[i-1]) {
+			r1 = i + 1
+			break
+		}
+	}
+	for i := r1 + 1; i < len(word); i++ {
+		if !isRuVowel(word[i]) && isRuVowel(word[i-1]) {
+			r2 = i + 1
+			break
+		}
+	}
+	return rv, r2
+}
+
+// ruEnding is a suffix with flag telling that it must follow а or я
+type ruEnding struct {
+	suffix []rune
+	group1 bool
+}
+
+func ruEndings(group1, group2 []string) []ruEnding {
+	endings := make([]ruEnding, 0, len(group1)+len(group2))
+	for _, e := range group1 {
+		endings = append(endings, ruEnding{[]rune(e), true})
+	}
+	for _, e := range group2 {
+		endings = append(endings, ruEnding{[]rune(e), false})
+	}
+	return endings
+}
+
+var (
+	ruPerfectiveGerundEndings = ruEndings(ruPerfectiveGerund1, ruPerfectiveGerund2)
+	ruAdjectiveEndings        = ruEndings(nil, ruAdjective)
+	ruParticipleEndings       = ruEndings(ruParticiple1, ruParticiple2)
+	ruReflexiveEndings        = ruEndings(nil, ruReflexive)
+	ruVerbEndings             = ruEndings(ruVerb1, ruVerb2)
+	ruNounEndings             = ruEndings(nil, ruNoun)
+	ruSuperlativeEndings      = ruEndings(nil, ruSuperlative)
+	ruDerivationalEndings     = ruEndings(nil, ruDerivational)
+)
+
+func hasRuneSuffix(word, suffix []rune) bool {
+	if len(suffix) > len(word) {
+		return false
+	}
+	for i := range suffix {
+		if word[len(word)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ruRemove removes the longest of endings which lies in region starting at from
+func ruRemove(word []rune, from int, endings []ruEnding) ([]rune, bool) {
+	var found *ruEnding
+	for i := range endings {
+		e := &endings[i]
+		if hasRuneSuffix(word, e.suffix) && (found == nil || len(e.suffix) > len(found.suffix)) {
+			found = e
+		}
+	}
+	if found == nil {
+		return word, false
+	}
+
+	start := len(word) - len(found.suffix)
+	if start < from {
+		return word, false
+	}
+	if found.group1 {
+		if start-1 < from || !containsRune(ruGroup1Preceding, word[start-1]) {
+			return word, false
+		}
+	}
+	return word[:start], true
+}
+
+func containsRune(s string, r rune) bool {
+	for _, c := range s {
+		if c == r {
+			return true
+		}
+	}
+	return false
+}
+
+func stemRussian(s string) string {
+	word := []rune(s)
+	rv, r2 := ruRegions(word)
+
+	// step 1
+	if w, ok := ruRemove(word, rv, ruPerfectiveGerundEndings); ok {
+		word = w
+	} else {
+		word, _ = ruRemove(word, rv, ruReflexiveEndings)
+		if w, ok := ruRemove(word, rv, ruAdjectiveEndings); ok {
+			word, _ = ruRemove(w, rv, ruParticipleEndings)
+		} else if w, ok := ruRemove(word, rv, ruVerbEndings); ok {
+			word = w
+		} else {
+			word, _ = ruRemove(word, rv, ruNounEndings)
+		}
+	}
+
+	// step 2
+	if len(word) > rv && word[len(word)-1] == 'и' {
+		word = word[:len(word)-1]
+	}
+
+	// step 3
+	word, _ = ruRemove(word, r2, ruDerivationalEndings)
+
+	// step 4
+	if hasRuneSuffix(word, []rune("нн")) && len(word)-2 >= rv {
+		word = word[:len(word)-1]
+	} else if w, ok := ruRemove(word, rv, ruSuperlativeEndings); ok {
+		word = w
+		if hasRuneSuffix(word, []rune("нн")) && len(word)-2 >= rv {
+			word = word[:len(word)-1]
+		}
+	} else if len(word) > rv && word[len(word)-1] == 'ь' {
+		word = word[:len(word)-1]
+	}
+
+	return string(word)
+}
@@ -0,0 +1,48 @@
+package index
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"running", "run"},
+		{"hopping", "hop"},
+		{"relational", "relat"},
+		{"generalization", "gener"},
+		{"motoring", "motor"},
+		{"sky", "sky"},
+		{"холодильники", "холодильник"},
+		{"программистов", "программист"},
+		{"котиками", "котик"},
+		{"красивая", "красив"},
+		{"красивый", "красив"},
+		{"бегать", "бега"},
+		{"вечерами", "вечер"},
+		{"ёжик", "ежик"},
+		{"slackware123", "slackware123"},
+		{"линуxа", "линуxа"},
+	}
+
+	for _, test := range tests {
+		if got := stem(test.word); got != test.want {
+			t.Errorf("stem(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	got := terms("<Xyz> Котики, КОТИКАМИ!.. и dogs")
+	want := []string{"xyz", "котик", "котик", "и", "dog"}
+	if len(got) != len(want) {
+		t.Fatalf("terms = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("terms = %q, want %q", got, want)
+		}
+	}
+}
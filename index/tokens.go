@@ -0,0 +1,45 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+)
+
+// words splits text to lower-cased words of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stem returns term of word, russian and english words are stemmed
+func stem(word string) string {
+	word = strings.Replace(word, "ё", "е", -1)
+
+	cyrillic, latin := false, false
+	for _, r := range word {
+		switch {
+		case r >= 'а' && r <= 'я':
+			cyrillic = true
+		case r >= 'a' && r <= 'z':
+			latin = true
+		}
+	}
+
+	switch {
+	case cyrillic && !latin:
+		return stemRussian(word)
+	case latin && !cyrillic:
+		return stemEnglish(word)
+	}
+	return word
+}
+
+// terms returns stemmed words of text in order
+func terms(text string) []string {
+	ws := words(text)
+	for i, w := range ws {
+		ws[i] = stem(w)
+	}
+	return ws
+}
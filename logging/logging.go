@@ -0,0 +1,257 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level of logging
+type Level int32
+
+// Levels
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+// Formats of log lines
+const (
+	LogfmtFormat = "logfmt"
+	JSONFormat   = "json"
+)
+
+// redacted replaces secrets in log lines
+const redacted = "<redacted>"
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var (
+	level = int32(InfoLevel)
+
+	mu      sync.Mutex
+	out     io.Writer = os.Stderr
+	format            = LogfmtFormat
+	secrets []string
+)
+
+// String returns name of level
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return fmt.Sprintf("level(%d)", l)
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses level name, empty name means info
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return InfoLevel, nil
+	}
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", name)
+}
+
+// SetLevel sets minimal level of messages to be logged
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// GetLevel returns current level
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+// SetFormat sets format of log lines, logfmt or json
+func SetFormat(f string) error {
+	if f != LogfmtFormat && f != JSONFormat {
+		return fmt.Errorf("unknown log format %q", f)
+	}
+	mu.Lock()
+	format = f
+	mu.Unlock()
+	return nil
+}
+
+// SetOutput sets destination of log lines
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	out = w
+	mu.Unlock()
+}
+
+// AddSecret makes logger replace s in every line
+func AddSecret(s string) {
+	if s == "" {
+		return
+	}
+	mu.Lock()
+	secrets = append(secrets, s)
+	mu.Unlock()
+}
+
+// Redact replaces known secrets in s
+func Redact(s string) string {
+	mu.Lock()
+	defer mu.Unlock()
+	return redact(s)
+}
+
+func redact(s string) string {
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, redacted, -1)
+	}
+	return s
+}
+
+// Logger adds fields to every line
+type Logger struct {
+	fields []field
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+var root = &Logger{}
+
+// With returns logger with fields given as key and value pairs
+func With(keyvals ...interface{}) *Logger {
+	return root.With(keyvals...)
+}
+
+// With returns logger with fields of l and given key and value pairs
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+len(keyvals)/2)
+	copy(fields, l.fields)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fields = append(fields, field{fmt.Sprint(keyvals[i]), keyvals[i+1]})
+	}
+	return &Logger{fields: fields}
+}
+
+// Debugf logs message with debug level
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(DebugLevel, format, args...)
+}
+
+// Infof logs message with info level
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(InfoLevel, format, args...)
+}
+
+// Warnf logs message with warn level
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(WarnLevel, format, args...)
+}
+
+// Errorf logs message with error level
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(ErrorLevel, format, args...)
+}
+
+// Debugf logs message with debug level
+func Debugf(format string, args ...interface{}) {
+	root.logf(DebugLevel, format, args...)
+}
+
+// Infof logs message with info level
+func Infof(format string, args ...interface{}) {
+	root.logf(InfoLevel, format, args...)
+}
+
+// Warnf logs message with warn level
+func Warnf(format string, args ...interface{}) {
+	root.logf(WarnLevel, format, args...)
+}
+
+// Errorf logs message with error level
+func Errorf(format string, args ...interface{}) {
+	root.logf(ErrorLevel, format, args...)
+}
+
+func (l *Logger) logf(lvl Level, msg string, args ...interface{}) {
+	if lvl < GetLevel() {
+		return
+	}
+
+	fields := make([]field, 0, len(l.fields)+3)
+	fields = append(fields,
+		field{"time", time.Now().Format(time.RFC3339Nano)},
+		field{"level", lvl.String()},
+		field{"msg", fmt.Sprintf(msg, args...)})
+	fields = append(fields, l.fields...)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var line string
+	if format == JSONFormat {
+		line = jsonLine(fields)
+	} else {
+		line = logfmtLine(fields)
+	}
+	io.WriteString(out, redact(line)+"\n")
+}
+
+func logfmtLine(fields []field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		value := valueString(f.value)
+		if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+			value = strconv.Quote(value)
+		}
+		parts[i] = f.key + "=" + value
+	}
+	return strings.Join(parts, " ")
+}
+
+func jsonLine(fields []field) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		key, _ := json.Marshal(f.key)
+		value, err := json.Marshal(jsonValue(f.value))
+		if err != nil {
+			value, _ = json.Marshal(valueString(f.value))
+		}
+		parts[i] = string(key) + ":" + string(value)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.Seconds()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
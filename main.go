@@ -1,11 +1,45 @@
 package main
 
 import (
-	"log"
+	"flag"
+	"os"
 
 	"github.com/AnisimoffNikita/go_bash_telgram_bot/bot"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/database"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/helper"
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/logging"
 )
 
 func main() {
-	log.Fatal(bot.StartBot())
+	configPath := flag.String("config", "", "path to bot config (default "+bot.ConfigPath+")")
+	dbConfigPath := flag.String("db-config", "", "path to database config (default "+database.ConfigPath+")")
+	botFlags := helper.NewFlags(flag.CommandLine, "", &bot.Config{})
+	dbFlags := helper.NewFlags(flag.CommandLine, "db-", &database.Config{})
+	flag.Parse()
+
+	load := func() (bot.Config, database.Config, error) {
+		config, err := bot.LoadConfig(pathOr(*configPath, bot.ConfigPath), *configPath != "", botFlags)
+		if err != nil {
+			return bot.Config{}, database.Config{}, err
+		}
+
+		dbConfig, err := database.LoadConfig(pathOr(*dbConfigPath, database.ConfigPath), *dbConfigPath != "", dbFlags)
+		if err != nil {
+			return bot.Config{}, database.Config{}, err
+		}
+		return config, dbConfig, nil
+	}
+
+	if err := bot.StartBot(load); err != nil {
+		logging.Errorf("%s", err)
+		os.Exit(1)
+	}
+}
+
+// pathOr returns path if it was given, otherwise default one
+func pathOr(path, def string) string {
+	if path != "" {
+		return path
+	}
+	return def
 }
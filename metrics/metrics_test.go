@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestLabelsString(t *testing.T) {
+	tests := []struct {
+		names  []string
+		values []string
+		want   string
+	}{
+		{nil, nil, ""},
+		{[]string{"a"}, []string{"x"}, `{a="x"}`},
+		{[]string{"a", "b"}, []string{"x"}, `{a="x",b=""}`},
+		{[]string{"path"}, []string{`C:\dir`}, `{path="C:\\dir"}`},
+		{[]string{"q"}, []string{`say "hi"`}, `{q="say \"hi\""}`},
+		{[]string{"text"}, []string{"two\nlines"}, `{text="two\nlines"}`},
+		{[]string{"text"}, []string{"цитата {x}"}, `{text="цитата {x}"}`},
+	}
+	for _, test := range tests {
+		if got := labelsString(test.names, test.values); got != test.want {
+			t.Errorf("labelsString(%q, %q) = %s, want %s", test.names, test.values, got, test.want)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{1.5, "1.5"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+	}
+	for _, test := range tests {
+		if got := formatFloat(test.v); got != test.want {
+			t.Errorf("formatFloat(%v) = %s, want %s", test.v, got, test.want)
+		}
+	}
+}
+
+// metrics are made without register, so tests can run many times
+func TestCounterWrite(t *testing.T) {
+	c := &Counter{name: "updates_total", help: "Updates", labels: []string{"kind"}, values: make(map[string]float64)}
+	c.Inc("message")
+	c.Add(2, `"quoted"`)
+	c.Inc("message")
+
+	var buf bytes.Buffer
+	c.write(&buf)
+	want := `# HELP updates_total Updates
+# TYPE updates_total counter
+updates_total{kind="\"quoted\""} 2
+updates_total{kind="message"} 2
+`
+	if buf.String() != want {
+		t.Errorf("counter is written as\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestHistogramWrite(t *testing.T) {
+	h := &Histogram{
+		name:    "duration_seconds",
+		help:    "Duration",
+		labels:  []string{"processor"},
+		buckets: []float64{0.1, 1},
+		series:  make(map[string]*histogramSeries),
+	}
+	h.Observe(0.05, "a\\b")
+	h.Observe(0.5, "a\\b")
+	h.Observe(2, "a\\b")
+
+	var buf bytes.Buffer
+	h.write(&buf)
+	want := `# HELP duration_seconds Duration
+# TYPE duration_seconds histogram
+duration_seconds_bucket{processor="a\\b",le="0.1"} 1
+duration_seconds_bucket{processor="a\\b",le="1"} 2
+duration_seconds_bucket{processor="a\\b",le="+Inf"} 3
+duration_seconds_sum{processor="a\\b"} 2.55
+duration_seconds_count{processor="a\\b"} 3
+`
+	if buf.String() != want {
+		t.Errorf("histogram is written as\n%s\nwant\n%s", buf.String(), want)
+	}
+}
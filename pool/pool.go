@@ -3,6 +3,7 @@ package pool
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,14 +25,33 @@ type Task struct {
 
 // Pool ...
 type Pool struct {
+	waiting  int64
+	timeouts uint64
+
+	mu          sync.Mutex
 	concurrency int
+	running     int
 	tasksChan   chan *Task
-	wg          sync.WaitGroup
+	// resized is closed when concurrency changes, so idle workers check it
+	resized chan struct{}
+	wg      sync.WaitGroup
 }
 
-// Size ...
+// Size returns number of running workers
 func (p *Pool) Size() int {
-	return p.concurrency
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
+
+// QueueDepth returns number of tasks waiting for a free worker
+func (p *Pool) QueueDepth() int {
+	return int(atomic.LoadInt64(&p.waiting))
+}
+
+// Timeouts returns number of tasks which timed out waiting for a worker
+func (p *Pool) Timeouts() uint64 {
+	return atomic.LoadUint64(&p.timeouts)
 }
 
 // NewPool ...
@@ -39,17 +59,49 @@ func NewPool(concurrency int) *Pool {
 	return &Pool{
 		concurrency: concurrency,
 		tasksChan:   make(chan *Task),
+		resized:     make(chan struct{}),
 	}
 }
 
 // Run ...
 func (p *Pool) Run() {
-	for i := 0; i < p.concurrency; i++ {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.startWorkers()
+}
+
+// Resize changes number of workers of running pool, extra workers finish
+// their current tasks and stop
+func (p *Pool) Resize(concurrency int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.concurrency = concurrency
+	p.startWorkers()
+	close(p.resized)
+	p.resized = make(chan struct{})
+}
+
+// startWorkers starts workers until there are concurrency of them, mu must be held
+func (p *Pool) startWorkers() {
+	for ; p.running < p.concurrency; p.running++ {
 		p.wg.Add(1)
 		go p.runWorker()
 	}
 }
 
+// retire stops worker if there are more of them than needed and returns
+// channel closed on next resize otherwise
+func (p *Pool) retire() (<-chan struct{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running > p.concurrency {
+		p.running--
+		return nil, true
+	}
+	return p.resized, false
+}
+
 // Stop ...
 func (p *Pool) Stop() {
 	close(p.tasksChan)
@@ -64,7 +116,9 @@ func (p *Pool) AddTaskSync(f Func) interface{} {
 	}
 
 	t.wg.Add(1)
+	atomic.AddInt64(&p.waiting, 1)
 	p.tasksChan <- &t
+	atomic.AddInt64(&p.waiting, -1)
 	t.wg.Wait()
 
 	return t.result
@@ -78,10 +132,13 @@ func (p *Pool) AddTaskSyncTimed(f Func, timeout time.Duration) (interface{}, err
 	}
 
 	t.wg.Add(1)
+	atomic.AddInt64(&p.waiting, 1)
 	select {
 	case p.tasksChan <- &t:
-		break
+		atomic.AddInt64(&p.waiting, -1)
 	case <-time.After(timeout):
+		atomic.AddInt64(&p.waiting, -1)
+		atomic.AddUint64(&p.timeouts, 1)
 		return nil, ErrJobTimedOut
 	}
 
@@ -91,9 +148,24 @@ func (p *Pool) AddTaskSyncTimed(f Func, timeout time.Duration) (interface{}, err
 }
 
 func (p *Pool) runWorker() {
-	for t := range p.tasksChan {
-		t.result = t.f()
-		t.wg.Done()
+	defer p.wg.Done()
+	for {
+		resized, retired := p.retire()
+		if retired {
+			return
+		}
+
+		select {
+		case t, ok := <-p.tasksChan:
+			if !ok {
+				p.mu.Lock()
+				p.running--
+				p.mu.Unlock()
+				return
+			}
+			t.result = t.f()
+			t.wg.Done()
+		case <-resized:
+		}
 	}
-	p.wg.Done()
 }
@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// maxParallel runs n tasks at once and returns the most of them running together
+func maxParallel(p *Pool, n int) int {
+	var running, max int64
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.AddTaskSync(func() interface{} {
+				now := atomic.AddInt64(&running, 1)
+				for {
+					old := atomic.LoadInt64(&max)
+					if now <= old || atomic.CompareAndSwapInt64(&max, old, now) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt64(&running, -1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	return int(max)
+}
+
+// waitSize waits until pool has size workers
+func waitSize(t *testing.T, p *Pool, size int) {
+	deadline := time.Now().Add(time.Second)
+	for p.Size() != size {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool has %d workers, want %d", p.Size(), size)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolResize(t *testing.T) {
+	p := NewPool(2)
+	p.Run()
+	defer p.Stop()
+
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"initial", 2},
+		{"grow", 4},
+		{"shrink", 1},
+		{"same", 1},
+		{"grow again", 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name != "initial" {
+				p.Resize(test.size)
+			}
+			waitSize(t, p, test.size)
+			if got := maxParallel(p, 8); got != test.size {
+				t.Errorf("%d tasks ran at once, want %d", got, test.size)
+			}
+		})
+	}
+}
+
+func TestPoolTimedOut(t *testing.T) {
+	p := NewPool(1)
+	p.Run()
+	defer p.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go p.AddTaskSync(func() interface{} {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	_, err := p.AddTaskSyncTimed(func() interface{} { return nil }, 10*time.Millisecond)
+	if err != ErrJobTimedOut {
+		t.Errorf("error = %v, want %v", err, ErrJobTimedOut)
+	}
+	if p.Timeouts() != 1 {
+		t.Errorf("timeouts = %d, want 1", p.Timeouts())
+	}
+	close(release)
+}
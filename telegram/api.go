@@ -13,6 +13,9 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/AnisimoffNikita/go_bash_telgram_bot/metrics"
 )
 
 // API endpoint mask
@@ -29,6 +32,9 @@ var (
 	ErrJobTimedOut  = errors.New("job request timed out")
 )
 
+var apiErrors = metrics.NewCounter("telegram_api_errors_total",
+	"Telegram API errors by code, network errors have code \"network\"", "code")
+
 // BotAPI struct
 type BotAPI struct {
 	Token  string
@@ -42,10 +48,15 @@ func (bot *BotAPI) MakeRequest(method string, params url.Values) (APIResponse, e
 
 	resp, err := bot.Client.PostForm(endpoint, params)
 	if err != nil {
-		return APIResponse{}, err
+		apiErrors.Inc("network")
+		return APIResponse{}, bot.redact(err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		apiErrors.Inc(strconv.Itoa(resp.StatusCode))
+	}
+
 	if resp.StatusCode == http.StatusForbidden {
 		return APIResponse{}, ErrAPIForbidden
 	}
@@ -67,12 +78,26 @@ func (bot *BotAPI) MakeRequest(method string, params url.Values) (APIResponse, e
 	}
 
 	if !apiResp.Ok {
+		apiErrors.Inc(strconv.Itoa(apiResp.ErrorCode))
 		return apiResp, errors.New(apiResp.Description)
 	}
 
 	return apiResp, nil
 }
 
+// redact removes token from url of request error
+func (bot *BotAPI) redact(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok || bot.Token == "" {
+		return err
+	}
+	return &url.Error{
+		Op:  urlErr.Op,
+		URL: strings.Replace(urlErr.URL, bot.Token, "<token>", -1),
+		Err: urlErr.Err,
+	}
+}
+
 func (bot *BotAPI) makeMessageRequest(endpoint string, params url.Values) (Message, error) {
 	resp, err := bot.MakeRequest(endpoint, params)
 	if err != nil {
@@ -93,9 +118,14 @@ func (bot *BotAPI) UploadFile(method string, params map[string]string, param str
 		return APIResponse{}, err
 	}
 
+	return bot.doUpload(req)
+}
+
+func (bot *BotAPI) doUpload(req *http.Request) (APIResponse, error) {
 	res, err := bot.Client.Do(req)
 	if err != nil {
-		return APIResponse{}, err
+		apiErrors.Inc("network")
+		return APIResponse{}, bot.redact(err)
 	}
 	defer res.Body.Close()
 
@@ -111,6 +141,7 @@ func (bot *BotAPI) UploadFile(method string, params map[string]string, param str
 	}
 
 	if !apiResp.Ok {
+		apiErrors.Inc(strconv.Itoa(apiResp.ErrorCode))
 		return APIResponse{}, errors.New(apiResp.Description)
 	}
 
@@ -124,13 +155,17 @@ func (bot *BotAPI) uploadFileRequest(method string, params map[string]string, pa
 	}
 	defer file.Close()
 
+	return bot.uploadRequest(method, params, param, filepath.Base(path), file)
+}
+
+func (bot *BotAPI) uploadRequest(method string, params map[string]string, param string, name string, data io.Reader) (*http.Request, error) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(param, filepath.Base(path))
+	part, err := writer.CreateFormFile(param, name)
 	if err != nil {
 		return nil, err
 	}
-	_, err = io.Copy(part, file)
+	_, err = io.Copy(part, data)
 	if err != nil {
 		return nil, err
 	}
@@ -232,3 +267,68 @@ func (bot *BotAPI) SendTextWithoutKeybord(chatID int, text string) (Message, err
 
 	return message, nil
 }
+
+// EditMessageText Method
+func (bot *BotAPI) EditMessageText(chatID int, messageID int, text string) (Message, error) {
+	params := url.Values{}
+	params.Add("chat_id", strconv.Itoa(chatID))
+	params.Add("message_id", strconv.Itoa(messageID))
+	params.Add("text", text)
+
+	message, err := bot.makeMessageRequest("editMessageText", params)
+
+	if err != nil {
+		return Message{}, err
+	}
+
+	return message, nil
+}
+
+// SendPhoto Method, photo is an address which telegram downloads itself,
+// keybord is not changed if it is nil
+func (bot *BotAPI) SendPhoto(chatID int, photo string, caption string, keybord *ReplyKeyboardMarkup) (Message, error) {
+	params := url.Values{}
+	params.Add("chat_id", strconv.Itoa(chatID))
+	params.Add("photo", photo)
+	params.Add("caption", caption)
+
+	if keybord != nil {
+		keybordJSON, err := json.Marshal(keybord)
+		if err != nil {
+			return Message{}, ErrAPIKeybord
+		}
+		params.Add("reply_markup", string(keybordJSON))
+	}
+
+	return bot.makeMessageRequest("sendPhoto", params)
+}
+
+// UploadPhoto Method, keybord is not changed if it is nil
+func (bot *BotAPI) UploadPhoto(chatID int, name string, data []byte, caption string, keybord *ReplyKeyboardMarkup) (Message, error) {
+	params := map[string]string{
+		"chat_id": strconv.Itoa(chatID),
+		"caption": caption,
+	}
+
+	if keybord != nil {
+		keybordJSON, err := json.Marshal(keybord)
+		if err != nil {
+			return Message{}, ErrAPIKeybord
+		}
+		params["reply_markup"] = string(keybordJSON)
+	}
+
+	req, err := bot.uploadRequest("sendPhoto", params, "photo", name, bytes.NewReader(data))
+	if err != nil {
+		return Message{}, err
+	}
+
+	resp, err := bot.doUpload(req)
+	if err != nil {
+		return Message{}, err
+	}
+
+	var message Message
+	json.Unmarshal(resp.Result, &message)
+	return message, nil
+}